@@ -0,0 +1,28 @@
+module "repository" {
+  source  = "../.."
+  context = module.this.context
+
+  enabled = module.this.enabled
+
+  name       = module.this.id
+  visibility = var.visibility
+  fork       = var.fork
+
+  security_and_analysis = var.advanced_security ? {
+    advanced_security = true
+  } : null
+}
+
+module "example" {
+  source = "../../modules/code-scanning"
+
+  enabled = module.this.enabled
+
+  repository         = module.repository.full_name
+  repository_node_id = module.repository.node_id
+  visibility         = var.visibility
+  advanced_security  = var.advanced_security
+
+  default_setup   = var.default_setup
+  github_base_url = var.github_base_url
+}
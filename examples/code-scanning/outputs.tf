@@ -0,0 +1,9 @@
+output "full_name" {
+  description = "Full name of the created repository"
+  value       = module.repository.full_name
+}
+
+output "default_setup" {
+  description = "Code scanning default setup of the repository, as read from GitHub"
+  value       = module.example.default_setup
+}
@@ -0,0 +1,44 @@
+variable "owner" {
+  description = "Owner of the repository, the `GITHUB_OWNER` environment variable when null"
+  type        = string
+  default     = null
+}
+
+variable "visibility" {
+  description = "Visibility of the repository. Must be public, private, or internal."
+  type        = string
+  default     = "public"
+}
+
+variable "fork" {
+  description = "Create the repository as a fork of an existing repository, to scan its source code"
+  type = object({
+    source_owner = string
+    source_repo  = string
+  })
+  default = null
+}
+
+variable "advanced_security" {
+  description = "Enable GitHub Advanced Security, required for private and internal repositories"
+  type        = bool
+  default     = false
+}
+
+variable "default_setup" {
+  description = "CodeQL code scanning default setup. Accepts the same attributes as the code-scanning submodule."
+  type        = any
+  default     = {}
+}
+
+variable "github_base_url" {
+  description = "Base URL of the GitHub API of the provider and of scripts/github-api.sh, the `GITHUB_BASE_URL` environment variable or github.com when null"
+  type        = string
+  default     = null
+}
+
+variable "github_app_auth" {
+  description = "Authenticate the provider as the GitHub App installation of the `GITHUB_APP_ID`, `GITHUB_APP_INSTALLATION_ID` and `GITHUB_APP_PEM_FILE` environment variables"
+  type        = bool
+  default     = false
+}
@@ -2,12 +2,17 @@ module "example" {
   source  = "../.."
   context = module.this.context
 
+  enabled = module.this.enabled
+
   name = module.this.id
 
   description = var.description
   visibility  = var.visibility
 
   template = var.template
+  fork     = var.fork
+
+  recreate_on_template_change = var.recreate_on_template_change
 
   homepage_url = var.homepage_url
   topics       = var.topics
@@ -15,6 +20,9 @@ module "example" {
   archived           = var.archived
   archive_on_destroy = var.archive_on_destroy
 
+  deletion_protection = var.deletion_protection
+  allow_destroy       = var.allow_destroy
+
   is_template = var.is_template
 
   has_discussions = var.has_discussions
@@ -51,9 +59,18 @@ module "example" {
   enable_vulnerability_alerts = var.enable_vulnerability_alerts
   security_and_analysis       = var.security_and_analysis
 
+  github_base_url = var.github_base_url
+
+  enable_dependabot_security_updates = var.enable_dependabot_security_updates
+
+  manage_default_branch = var.manage_default_branch
+  rename_default_branch = var.rename_default_branch
+
   custom_properties = var.custom_properties
   environments      = var.environments
 
+  environment_secrets = var.environment_secrets
+
   variables   = var.variables
   secrets     = var.secrets
   deploy_keys = var.deploy_keys
@@ -61,7 +78,9 @@ module "example" {
   labels      = var.labels
   teams       = var.teams
   users       = var.users
-  rulesets    = var.rulesets
+
+  collaborators_mode = var.collaborators_mode
+  rulesets           = var.rulesets
 
 }
 
@@ -43,11 +43,22 @@ output "primary_language" {
   value       = module.example.primary_language
 }
 
+output "environments" {
+  description = "Names of the environments of the repository"
+  value       = module.example.environments
+}
+
 output "webhooks_urls" {
   description = "Webhooks URLs"
   value       = module.example.webhooks_urls
 }
 
+output "deploy_keys_private_keys" {
+  description = "Private keys of the generated deploy keys in OpenSSH format"
+  value       = module.example.deploy_keys_private_keys
+  sensitive   = true
+}
+
 output "collaborators_invitation_ids" {
   description = "Collaborators invitation IDs"
   value       = module.example.collaborators_invitation_ids
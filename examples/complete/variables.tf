@@ -1,6 +1,7 @@
 variable "owner" {
-  description = "Owner of the repository"
+  description = "Owner of the repository, the `GITHUB_OWNER` environment variable when null"
   type        = string
+  default     = null
 }
 
 variable "template" {
@@ -36,6 +37,22 @@ variable "homepage_url" {
   default     = null
 }
 
+variable "recreate_on_template_change" {
+  description = "Recreate the repository when template is changed after creation. By default the plan is refused, as the template is only used when the repository is created."
+  type        = bool
+  default     = false
+  nullable    = false
+}
+
+variable "fork" {
+  description = "Create the repository as a fork of an existing repository. Can not be combined with template, auto_init, gitignore_template or license_template."
+  type = object({
+    source_owner = string
+    source_repo  = string
+  })
+  default = null
+}
+
 variable "archived" {
   description = "Whether the repository is archived"
   type        = bool
@@ -158,6 +175,19 @@ variable "default_branch" {
   default     = "main"
 }
 
+variable "manage_default_branch" {
+  description = "Manage the default branch of the repository. Defaults to true for repositories created with auto_init. Repositories created from a template or as a fork keep the default branch of their source, set it to true to switch or rename it to default_branch. Set to true for imported repositories."
+  type        = bool
+  default     = null
+}
+
+variable "rename_default_branch" {
+  description = "Rename the current default branch to default_branch instead of switching to an existing branch"
+  type        = bool
+  default     = false
+  nullable    = false
+}
+
 variable "web_commit_signoff_required" {
   description = "Require signoff on web commits"
   type        = bool
@@ -200,6 +230,12 @@ variable "enable_vulnerability_alerts" {
   default     = true
 }
 
+variable "enable_dependabot_security_updates" {
+  description = "Enable Dependabot security updates (automated security fixes). Requires vulnerability alerts to be enabled. Leave null to not manage it."
+  type        = bool
+  default     = null
+}
+
 variable "allow_update_branch" {
   description = "Allow updating the branch"
   type        = bool
@@ -207,21 +243,54 @@ variable "allow_update_branch" {
 }
 
 variable "security_and_analysis" {
-  description = "Security and analysis settings"
+  description = "Security and analysis settings. Settings left null are not managed."
   type = object({
-    advanced_security               = bool
-    secret_scanning                 = bool
-    secret_scanning_push_protection = bool
+    // Can not be changed for public repositories
+    advanced_security = optional(bool, false)
+    // Can not be changed for public repositories
+    code_security                   = optional(bool, null)
+    secret_scanning                 = optional(bool, false)
+    secret_scanning_push_protection = optional(bool, false)
+    // Require secret_scanning
+    secret_scanning_ai_detection          = optional(bool, null)
+    secret_scanning_non_provider_patterns = optional(bool, null)
+    secret_scanning_validity_checks       = optional(bool, null)
   })
   default = null
 }
 
+variable "github_base_url" {
+  description = "Base URL of the GitHub API of the provider and of scripts/github-api.sh, the `GITHUB_BASE_URL` environment variable or github.com when null"
+  type        = string
+  default     = null
+}
+
+variable "github_app_auth" {
+  description = "Authenticate the provider as the GitHub App installation of the `GITHUB_APP_ID`, `GITHUB_APP_INSTALLATION_ID` and `GITHUB_APP_PEM_FILE` environment variables"
+  type        = bool
+  default     = false
+}
+
 variable "archive_on_destroy" {
   description = "Archive the repository on destroy"
   type        = bool
   default     = false
 }
 
+variable "deletion_protection" {
+  description = "Protect the repository from being destroyed or replaced. The plan is refused when the module is disabled, or when a template or fork change would replace the repository. Requires archive_on_destroy, so the repository is archived instead of deleted when the module is removed. Replacements requested with `terraform apply -replace` are not refused. The source of existing forks is read with scripts/github-api.sh. A disabled module is refused for any existing repository of the name, managed or not. To adopt an existing repository, keep the module enabled and import it into `github_repository.default[0]`."
+  type        = bool
+  default     = false
+  nullable    = false
+}
+
+variable "allow_destroy" {
+  description = "Allow the repository to be destroyed or replaced when deletion_protection is enabled"
+  type        = bool
+  default     = false
+  nullable    = false
+}
+
 variable "autolink_references" {
   description = "Autolink references"
   type = map(object({
@@ -261,7 +330,7 @@ variable "custom_properties" {
 }
 
 variable "environments" {
-  description = "Environments for the repository. Enviroment secrets should be encrypted using the GitHub public key in Base64 format if prefixed with nacl:. Read more: https://docs.github.com/en/actions/security-for-github-actions/encrypted-secrets"
+  description = "Environments for the repository. Environment secrets are configured with `environment_secrets`, `secrets` is deprecated and refused"
   type = map(object({
     wait_timer          = optional(number, 0)
     can_admins_bypass   = optional(bool, false)
@@ -278,11 +347,11 @@ variable "environments" {
       }), null)
     }), null)
     variables = optional(map(string), null)
-    secrets   = optional(map(string), null)
+    // Deprecated, refused in favor of environment_secrets
+    secrets = optional(map(string), null)
   }))
-  default   = {}
-  sensitive = true
-  nullable  = false
+  default  = {}
+  nullable = false
 
   validation {
     condition     = alltrue([for k, v in var.environments : try(length(v.reviewers.teams) <= 6, true)])
@@ -303,9 +372,17 @@ variable "environments" {
     condition     = alltrue([for k, v in var.environments : try(alltrue([for k, v in v.variables : can(regex("^[a-zA-Z0-9_]+$", k))]), true)])
     error_message = "Environment variables must be alphanumeric and underscores only, can not start with a number"
   }
+}
+
+variable "environment_secrets" {
+  description = "Secrets for the repository environments keyed by environment name (if prefixed with nacl: it should be encrypted value using the GitHub public key in Base64 format. Read more: https://docs.github.com/en/actions/security-for-github-actions/encrypted-secrets)"
+  type        = map(map(string))
+  default     = {}
+  sensitive   = true
+  nullable    = false
 
   validation {
-    condition     = alltrue([for k, v in var.environments : try(alltrue([for k, v in v.secrets : can(regex("^[a-zA-Z0-9_]+$", k))]), true)])
+    condition     = alltrue([for k, v in var.environment_secrets : alltrue([for k, v in v : can(regex("^[a-zA-Z0-9_]+$", k))])])
     error_message = "Environment secrets must be alphanumeric and underscores only, can not start with a number"
   }
 }
@@ -337,11 +414,20 @@ variable "secrets" {
 }
 
 variable "deploy_keys" {
-  description = "Deploy keys for the repository"
+  description = "Deploy keys for the repository. Set `generate` instead of `key` to have the module generate the key pair."
   type = map(object({
     title     = string
-    key       = string
+    key       = optional(string, null)
     read_only = optional(bool, false)
+    generate = optional(object({
+      // ED25519, RSA
+      algorithm = optional(string, "ED25519")
+      rsa_bits  = optional(number, 4096)
+      actions_secret = optional(object({
+        repository  = string
+        secret_name = string
+      }), null)
+    }), null)
   }))
   default  = {}
   nullable = false
@@ -383,19 +469,31 @@ variable "labels" {
 }
 
 variable "teams" {
-  description = "A map of teams and their permissions for the repository"
+  description = "A map of teams and their permissions for the repository. Permission must be pull, triage, push, maintain, admin or the name of an organization custom repository role"
   type        = map(string)
   default     = {}
   nullable    = false
 }
 
 variable "users" {
-  description = "A map of users and their permissions for the repository"
+  description = "A map of users and their permissions for the repository. Permission must be pull, triage, push, maintain, admin or the name of an organization custom repository role"
   type        = map(string)
   default     = {}
   nullable    = false
 }
 
+variable "collaborators_mode" {
+  description = "Collaborators management mode. Must be authoritative or additive. Authoritative removes teams and users not managed by the module, additive leaves them untouched."
+  type        = string
+  default     = "authoritative"
+  nullable    = false
+
+  validation {
+    condition     = contains(["authoritative", "additive"], var.collaborators_mode)
+    error_message = "Collaborators mode must be authoritative or additive"
+  }
+}
+
 variable "rulesets" {
   description = "A map of rulesets to configure for the repository"
   type = map(object({
@@ -405,8 +503,12 @@ variable "rulesets" {
     bypass_actors = optional(list(object({
       // always, pull_request
       bypass_mode = string
-      actor_id    = optional(string, null)
-      // RepositoryRole, Team, Integration, OrganizationAdmin
+      // RepositoryRole: maintain, write, admin, custom role name or ID
+      // Team: team slug
+      // Integration: GitHub App slug or ID
+      // OrganizationAdmin, DeployKey: not used
+      actor_id = optional(string, null)
+      // RepositoryRole, Team, Integration, OrganizationAdmin, DeployKey
       actor_type = string
     })), [])
     conditions = object({
@@ -431,6 +533,7 @@ variable "rulesets" {
       creation         = optional(bool, false),
       deletion         = optional(bool, false),
       non_fast_forward = optional(bool, false),
+      // Deprecated, use pull_request
       required_pull_request_reviews = optional(object({
         dismiss_stale_reviews           = bool
         required_approving_review_count = number
@@ -468,8 +571,9 @@ variable "rulesets" {
       }), null),
       required_status_checks = optional(object({
         required_check = list(object({
-          context        = string
-          integration_id = optional(number, null)
+          context = string
+          // GitHub App slug or ID
+          integration_id = optional(string, null)
         }))
         strict_required_status_checks_policy = optional(bool, false)
         do_not_enforce_on_create             = optional(bool, false)
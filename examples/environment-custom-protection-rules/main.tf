@@ -0,0 +1,23 @@
+module "repository" {
+  source  = "../.."
+  context = module.this.context
+
+  enabled = module.this.enabled
+
+  name = module.this.id
+
+  environments = { for k, v in var.custom_protection_rules : k => {} }
+}
+
+module "example" {
+  source = "../../modules/environment-custom-protection-rules"
+
+  enabled = module.this.enabled
+
+  repository         = module.repository.full_name
+  repository_node_id = module.repository.node_id
+  environments       = module.repository.environments
+
+  custom_protection_rules = var.custom_protection_rules
+  github_base_url         = var.github_base_url
+}
@@ -0,0 +1,9 @@
+output "full_name" {
+  description = "Full name of the created repository"
+  value       = module.repository.full_name
+}
+
+output "integration_ids" {
+  description = "IDs of the GitHub Apps of the custom deployment protection rules keyed by environment name, as read from GitHub"
+  value       = module.example.integration_ids
+}
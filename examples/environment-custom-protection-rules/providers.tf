@@ -0,0 +1,11 @@
+provider "github" {
+  owner    = var.owner
+  base_url = var.github_base_url
+
+  # Reads the GitHub App installation of the GITHUB_APP_ID, GITHUB_APP_INSTALLATION_ID and GITHUB_APP_PEM_FILE
+  # environment variables
+  dynamic "app_auth" {
+    for_each = var.github_app_auth ? [1] : []
+    content {}
+  }
+}
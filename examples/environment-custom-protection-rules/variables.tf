@@ -0,0 +1,23 @@
+variable "owner" {
+  description = "Owner of the repository, the `GITHUB_OWNER` environment variable when null"
+  type        = string
+  default     = null
+}
+
+variable "custom_protection_rules" {
+  description = "Custom deployment protection rules keyed by environment name, as GitHub App slugs or IDs. The environments are created by the repository"
+  type        = map(list(string))
+  default     = {}
+}
+
+variable "github_base_url" {
+  description = "Base URL of the GitHub API of the provider and of scripts/github-api.sh, the `GITHUB_BASE_URL` environment variable or github.com when null"
+  type        = string
+  default     = null
+}
+
+variable "github_app_auth" {
+  description = "Authenticate the provider as the GitHub App installation of the `GITHUB_APP_ID`, `GITHUB_APP_INSTALLATION_ID` and `GITHUB_APP_PEM_FILE` environment variables"
+  type        = bool
+  default     = false
+}
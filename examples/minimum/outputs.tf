@@ -48,6 +48,12 @@ output "webhooks_urls" {
   value       = module.example.webhooks_urls
 }
 
+output "deploy_keys_private_keys" {
+  description = "Private keys of the generated deploy keys in OpenSSH format"
+  value       = module.example.deploy_keys_private_keys
+  sensitive   = true
+}
+
 output "collaborators_invitation_ids" {
   description = "Collaborators invitation IDs"
   value       = module.example.collaborators_invitation_ids
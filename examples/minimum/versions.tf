@@ -1,10 +1,18 @@
 terraform {
-  required_version = ">= 1.0"
+  required_version = ">= 1.5"
 
   required_providers {
     github = {
       source  = "integrations/github"
-      version = ">= 6.6.0"
+      version = ">= 6.7.0"
+    }
+    tls = {
+      source  = "hashicorp/tls"
+      version = ">= 4.0.0"
+    }
+    external = {
+      source  = "hashicorp/external"
+      version = ">= 2.3.0"
     }
   }
 }
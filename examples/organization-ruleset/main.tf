@@ -0,0 +1,7 @@
+module "example" {
+  source = "../../modules/organization-ruleset"
+
+  enabled = module.this.enabled
+
+  rulesets = var.rulesets
+}
@@ -0,0 +1,14 @@
+output "rulesets_etags" {
+  description = "Organization rulesets etags"
+  value       = module.example.rulesets_etags
+}
+
+output "rulesets_node_ids" {
+  description = "Organization rulesets node IDs"
+  value       = module.example.rulesets_node_ids
+}
+
+output "rulesets_rules_ids" {
+  description = "Organization rulesets rules IDs"
+  value       = module.example.rulesets_rules_ids
+}
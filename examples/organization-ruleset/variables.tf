@@ -0,0 +1,23 @@
+variable "owner" {
+  description = "Owner of the organization, the `GITHUB_OWNER` environment variable when null"
+  type        = string
+  default     = null
+}
+
+variable "github_base_url" {
+  description = "Base URL of the GitHub API of the provider, the `GITHUB_BASE_URL` environment variable or github.com when null"
+  type        = string
+  default     = null
+}
+
+variable "github_app_auth" {
+  description = "Authenticate the provider as the GitHub App installation of the `GITHUB_APP_ID`, `GITHUB_APP_INSTALLATION_ID` and `GITHUB_APP_PEM_FILE` environment variables"
+  type        = bool
+  default     = false
+}
+
+variable "rulesets" {
+  description = "A map of rulesets to configure for the organization. Accepts the same attributes as the organization-ruleset submodule."
+  type        = any
+  default     = {}
+}
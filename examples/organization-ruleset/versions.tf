@@ -0,0 +1,10 @@
+terraform {
+  required_version = ">= 1.4"
+
+  required_providers {
+    github = {
+      source  = "integrations/github"
+      version = ">= 6.7.0"
+    }
+  }
+}
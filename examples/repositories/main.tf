@@ -0,0 +1,9 @@
+module "example" {
+  source = "../../modules/repositories"
+
+  enabled = module.this.enabled
+
+  catalog_file = var.catalog_file
+  defaults     = var.defaults
+  repositories = var.repositories
+}
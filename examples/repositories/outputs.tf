@@ -0,0 +1,25 @@
+output "repositories" {
+  description = "Merged repository definitions keyed by repository"
+  value       = module.example.repositories
+  sensitive   = true
+}
+
+output "full_name" {
+  description = "Full names of the created repositories"
+  value       = module.example.full_name
+}
+
+output "html_url" {
+  description = "HTML URLs of the created repositories"
+  value       = module.example.html_url
+}
+
+output "repo_id" {
+  description = "Repository IDs of the created repositories"
+  value       = module.example.repo_id
+}
+
+output "rulesets_node_ids" {
+  description = "Rulesets node IDs keyed by repository"
+  value       = module.example.rulesets_node_ids
+}
@@ -0,0 +1,35 @@
+variable "owner" {
+  description = "Owner of the repository, the `GITHUB_OWNER` environment variable when null"
+  type        = string
+  default     = null
+}
+
+variable "github_base_url" {
+  description = "Base URL of the GitHub API of the provider, the `GITHUB_BASE_URL` environment variable or github.com when null"
+  type        = string
+  default     = null
+}
+
+variable "github_app_auth" {
+  description = "Authenticate the provider as the GitHub App installation of the `GITHUB_APP_ID`, `GITHUB_APP_INSTALLATION_ID` and `GITHUB_APP_PEM_FILE` environment variables"
+  type        = bool
+  default     = false
+}
+
+variable "catalog_file" {
+  description = "Path to a YAML catalog file with `defaults` and `repositories` keys"
+  type        = string
+  default     = null
+}
+
+variable "defaults" {
+  description = "Organization-wide defaults deep-merged into every repository definition. Takes precedence over the catalog defaults."
+  type        = any
+  default     = {}
+}
+
+variable "repositories" {
+  description = "A map of repository definitions keyed by repository name. Accepts the same attributes as the root module and takes precedence over the catalog repositories."
+  type        = any
+  default     = {}
+}
@@ -1,6 +1,83 @@
+locals {
+  vulnerability_alerts = var.visibility != "public" ? var.enable_vulnerability_alerts : true
+
+  deletion_protected = var.deletion_protection && !var.allow_destroy
+
+  # Base URL of scripts/github-api.sh, empty for the one of the GITHUB_BASE_URL environment variable
+  github_base_url = var.github_base_url != null ? var.github_base_url : ""
+
+  repository_exists = try(length(data.github_repository.existing[0].id) > 0, false)
+
+  # The template is only used when the repository is created, so a change of an existing repository is detected by
+  # comparing it with the template GitHub reports for the repository
+  template_existing = try(data.github_repository.existing[0].template[0], null)
+  template_changed = var.template != null && local.repository_exists && (
+    local.template_existing == null ||
+    lower(try(local.template_existing.owner, "")) != lower(var.template.owner) ||
+    lower(try(local.template_existing.repository, "")) != lower(var.template.name)
+  )
+
+  # Template the repository is created from. A changed template only replaces the repository when
+  # recreate_on_template_change is set, and is refused otherwise
+  template_created = var.template == null ? null : local.template_changed && !var.recreate_on_template_change ? (
+    local.template_existing != null ? lower(format("%s/%s", local.template_existing.owner, local.template_existing.repository)) : null
+  ) : lower(format("%s/%s", var.template.owner, var.template.name))
+
+  # The provider replaces the repository when it becomes or stops being a fork, or when its fork source changes. The
+  # source is only read for protected forks
+  fork_changed = local.repository_exists && (
+    try(data.github_repository.existing[0].fork, false) != (var.fork != null) ||
+    lower(try(data.external.fork_source[0].result.source, "")) != lower(try(format("%s/%s", var.fork.source_owner, var.fork.source_repo), ""))
+  )
+}
+
+# Returns no template and no ID while the repository does not exist yet
+data "github_repository" "existing" {
+  count = var.enabled && (var.template != null || local.deletion_protected) ? 1 : 0
+
+  name = var.name
+}
+
+# Source of an existing protected fork, which the data source does not return
+data "external" "fork_source" {
+  count = var.enabled && local.deletion_protected && var.fork != null && try(data.github_repository.existing[0].fork, false) ? 1 : 0
+
+  program = [
+    "env", "BASE_URL=${local.github_base_url}", "sh", "${path.module}/scripts/github-api.sh", "GET", "repos/${data.github_repository.existing[0].full_name}",
+    "{source: (.source.full_name // \"\")}",
+  ]
+}
+
+# Refuses the plan when the module is disabled for an existing protected repository. The state is not visible here, so
+# any existing repository of the name is refused, including one never managed by the module. Existing repositories are
+# adopted by importing them with the module enabled.
+data "github_repository" "deletion_protection" {
+  count = !var.enabled && local.deletion_protected ? 1 : 0
+
+  name = var.name
+
+  lifecycle {
+    postcondition {
+      condition     = try(length(self.id) == 0, true)
+      error_message = "Repository is protected by deletion_protection and can not be destroyed. Set allow_destroy to true to destroy it, or enable the module and import it to adopt a repository it does not manage"
+    }
+  }
+}
+
+# Updated when the template changes, which replaces the repository. It exists as long as the repository does, as
+# replace_triggered_by ignores the creation of the resource.
+resource "terraform_data" "template_change" {
+  count = var.enabled ? 1 : 0
+
+  input = local.template_created
+}
+
 resource "github_repository" "default" {
   count = var.enabled ? 1 : 0
 
+  # Renames are applied in place. Dependent resources follow the new name, so none of them is left on the previous
+  # name, which GitHub only redirects until another repository takes it. Variables, rulesets and Dependabot security
+  # updates follow it in place, the others are replaced, as the provider can not move them to another repository.
   name        = var.name
   description = var.description
   visibility  = var.visibility
@@ -17,6 +94,10 @@ resource "github_repository" "default" {
     }
   }
 
+  fork         = var.fork != null
+  source_owner = try(var.fork.source_owner, null)
+  source_repo  = try(var.fork.source_repo, null)
+
   archived           = var.archived
   archive_on_destroy = var.archive_on_destroy
 
@@ -49,7 +130,7 @@ resource "github_repository" "default" {
 
   web_commit_signoff_required = var.web_commit_signoff_required
 
-  vulnerability_alerts = var.visibility != "public" ? var.enable_vulnerability_alerts : true
+  vulnerability_alerts = local.vulnerability_alerts
 
   ignore_vulnerability_alerts_during_read = var.ignore_vulnerability_alerts_during_read
 
@@ -62,33 +143,154 @@ resource "github_repository" "default" {
           status = "enabled"
         }
       }
+      dynamic "code_security" {
+        for_each = var.visibility != "public" && security_and_analysis.value.code_security != null ? [security_and_analysis.value.code_security] : []
+        content {
+          status = code_security.value ? "enabled" : "disabled"
+        }
+      }
       secret_scanning {
         status = security_and_analysis.value.secret_scanning ? "enabled" : "disabled"
       }
       secret_scanning_push_protection {
         status = security_and_analysis.value.secret_scanning_push_protection ? "enabled" : "disabled"
       }
+      dynamic "secret_scanning_ai_detection" {
+        for_each = security_and_analysis.value.secret_scanning_ai_detection != null ? [security_and_analysis.value.secret_scanning_ai_detection] : []
+        content {
+          status = secret_scanning_ai_detection.value ? "enabled" : "disabled"
+        }
+      }
+      dynamic "secret_scanning_non_provider_patterns" {
+        for_each = security_and_analysis.value.secret_scanning_non_provider_patterns != null ? [security_and_analysis.value.secret_scanning_non_provider_patterns] : []
+        content {
+          status = secret_scanning_non_provider_patterns.value ? "enabled" : "disabled"
+        }
+      }
+      dynamic "secret_scanning_validity_checks" {
+        for_each = security_and_analysis.value.secret_scanning_validity_checks != null ? [security_and_analysis.value.secret_scanning_validity_checks] : []
+        content {
+          status = secret_scanning_validity_checks.value ? "enabled" : "disabled"
+        }
+      }
     }
   }
 
   lifecycle {
+    # The provider does not read include_all_branches back and can not apply any template change in place,
+    # template changes are handled with the template_change precondition and replacement trigger instead
     ignore_changes = [
-      template[0].include_all_branches,
+      template,
+    ]
+
+    replace_triggered_by = [
+      terraform_data.template_change,
     ]
+
+    precondition {
+      condition     = !local.template_changed || var.recreate_on_template_change
+      error_message = "Repository template can not be changed after creation. Set recreate_on_template_change to true to recreate the repository from the new template"
+    }
+
+    precondition {
+      condition     = !local.template_changed || !var.recreate_on_template_change || !local.deletion_protected
+      error_message = "Repository is protected by deletion_protection and can not be recreated on template change. Set allow_destroy to true to recreate it"
+    }
+
+    precondition {
+      condition     = !local.fork_changed || !local.deletion_protected
+      error_message = "Repository is protected by deletion_protection and can not be recreated on fork change. Set allow_destroy to true to recreate it"
+    }
+
+    # Removing the module skips every precondition, only archive_on_destroy is kept in the state
+    precondition {
+      condition     = var.archive_on_destroy || !local.deletion_protected
+      error_message = "Repository is protected by deletion_protection and must set archive_on_destroy to true, so it is archived instead of deleted when the module is removed. Set allow_destroy to true to delete it"
+    }
+
+    precondition {
+      condition     = !var.recreate_on_template_change || !var.archive_on_destroy
+      error_message = "Repository can not be recreated on template change when archive_on_destroy is enabled"
+    }
+
+    precondition {
+      condition     = var.fork == null || var.template == null
+      error_message = "Repository can not be created from a template and as a fork at the same time"
+    }
+
+    precondition {
+      condition     = var.fork == null || !var.auto_init && var.gitignore_template == null && var.license_template == null
+      error_message = "Fork repository does not support auto_init, gitignore_template or license_template"
+    }
+
+    precondition {
+      condition     = var.fork == null || try(data.github_repository.fork[0].visibility == var.visibility, true)
+      error_message = "Fork repository visibility must match the visibility of the source repository"
+    }
+
+    precondition {
+      condition     = var.visibility != "public" || !try(var.security_and_analysis.advanced_security, false) && try(var.security_and_analysis.code_security, null) == null
+      error_message = "Security and analysis advanced_security and code_security can not be changed for public repositories"
+    }
+
+    precondition {
+      condition = try(var.security_and_analysis.secret_scanning, false) || alltrue([
+        for k in ["secret_scanning_ai_detection", "secret_scanning_non_provider_patterns", "secret_scanning_validity_checks"] :
+        try(var.security_and_analysis[k], null) != true
+      ])
+      error_message = "Security and analysis secret_scanning_ai_detection, secret_scanning_non_provider_patterns and secret_scanning_validity_checks require secret_scanning"
+    }
   }
 }
 
+data "github_repository" "fork" {
+  count = var.enabled && var.fork != null ? 1 : 0
+
+  full_name = format("%s/%s", var.fork.source_owner, var.fork.source_repo)
+}
+
+locals {
+  # Repositories created empty have no branch to set as default, and the default branch of templates and forks may
+  # have another name than default_branch
+  manage_default_branch = var.manage_default_branch != null ? var.manage_default_branch : var.auto_init
+
+  # The default branch is only renamed while it has another name, as the resource is created again on a repository
+  # rename, when the branch already has its name
+  rename_default_branch = var.rename_default_branch && try(github_repository.default[0].default_branch != var.default_branch, true)
+}
+
 resource "github_branch_default" "default" {
-  count = var.enabled && var.auto_init ? 1 : 0
+  count = var.enabled && local.manage_default_branch ? 1 : 0
 
   repository = join("", github_repository.default[*].name)
   branch     = var.default_branch
+  rename     = local.rename_default_branch
+
+  lifecycle {
+    replace_triggered_by = [github_repository.default[0].node_id]
+  }
 
   depends_on = [
     github_repository.default
   ]
 }
 
+resource "github_repository_dependabot_security_updates" "default" {
+  count = var.enabled && var.enable_dependabot_security_updates != null ? 1 : 0
+
+  repository = join("", github_repository.default[*].name)
+  enabled    = var.enable_dependabot_security_updates
+
+  lifecycle {
+    replace_triggered_by = [github_repository.default[0].node_id]
+
+    precondition {
+      condition     = !var.enable_dependabot_security_updates || local.vulnerability_alerts
+      error_message = "Dependabot security updates require vulnerability alerts to be enabled"
+    }
+  }
+}
+
 resource "github_repository_autolink_reference" "default" {
   for_each = var.enabled ? var.autolink_references : {}
 
@@ -97,6 +299,12 @@ resource "github_repository_autolink_reference" "default" {
   key_prefix = each.value.key_prefix
 
   target_url_template = each.value.target_url_template
+
+  is_alphanumeric = each.value.is_alphanumeric
+
+  lifecycle {
+    replace_triggered_by = [github_repository.default[0].node_id]
+  }
 }
 
 
@@ -119,18 +327,21 @@ resource "github_repository_custom_property" "default" {
     each.value.multi_select != null ? each.value.multi_select :
     []
   ))
+
+  lifecycle {
+    replace_triggered_by = [github_repository.default[0].node_id]
+  }
 }
 
 locals {
   environments = var.enabled ? {
-    for k, v in nonsensitive(var.environments) : k => {
+    for k, v in var.environments : k => {
       wait_timer               = v.wait_timer
       can_admins_bypass        = v.can_admins_bypass
       prevent_self_review      = v.prevent_self_review
       reviewers                = try(v.reviewers, null)
       deployment_branch_policy = try(v.deployment_branch_policy, null)
       variables                = try(v.variables, null)
-      secrets                  = try(v.secrets, null)
     }
   } : {}
 
@@ -178,6 +389,10 @@ resource "github_repository_environment" "default" {
       custom_branch_policies = !deployment_branch_policy.value.protected_branches
     }
   }
+
+  lifecycle {
+    replace_triggered_by = [github_repository.default[0].node_id]
+  }
 }
 
 locals {
@@ -186,10 +401,10 @@ locals {
     c.variables != null ? { for k, v in c.variables : format("%s-%s", e, k) => { "environment" : e, "variable_name" : k, "variable_value" : v } } : {}
   ]...)
 
-  environment_secrets = merge([
-    for e, c in local.environments :
-    c.secrets != null ? { for k, v in c.secrets : format("%s-%s", e, k) => { "environment" : e, "secret_name" : k, "secret_value" : v } } : {}
-  ]...)
+  environment_secrets = var.enabled ? merge([
+    for e, c in nonsensitive(var.environment_secrets) :
+    { for k, v in c : format("%s-%s", e, k) => { "environment" : e, "secret_name" : k, "secret_value" : sensitive(v) } }
+  ]...) : {}
 
   environment_deployment_branch_policies = {
     for e, c in local.environments :
@@ -219,6 +434,10 @@ resource "github_repository_environment_deployment_policy" "tag_pattern" {
   environment = github_repository_environment.default[each.value.environment].environment
   tag_pattern = each.value.pattern
 
+  lifecycle {
+    replace_triggered_by = [github_repository.default[0].node_id]
+  }
+
   depends_on = [
     github_repository_environment.default
   ]
@@ -231,6 +450,10 @@ resource "github_repository_environment_deployment_policy" "branch_pattern" {
   environment    = github_repository_environment.default[each.value.environment].environment
   branch_pattern = each.value.pattern
 
+  lifecycle {
+    replace_triggered_by = [github_repository.default[0].node_id]
+  }
+
   depends_on = [
     github_repository_environment.default
   ]
@@ -243,16 +466,37 @@ resource "github_actions_environment_variable" "default" {
   environment   = github_repository_environment.default[each.value.environment].environment
   variable_name = each.value.variable_name
   value         = each.value.variable_value
+
+  # Renames are applied in place, like the repository. The variables of a replaced environment are deleted with it
+  lifecycle {
+    replace_triggered_by = [
+      github_repository.default[0].node_id,
+      github_repository_environment.default[each.value.environment].id,
+    ]
+  }
 }
 
 resource "github_actions_environment_secret" "default" {
   for_each = local.environment_secrets
 
   repository      = join("", github_repository.default[*].name)
-  environment     = github_repository_environment.default[each.value.environment].environment
+  environment     = each.value.environment
   secret_name     = each.value.secret_name
   plaintext_value = !startswith(each.value.secret_value, "nacl:") ? sensitive(each.value.secret_value) : null
   encrypted_value = startswith(each.value.secret_value, "nacl:") ? sensitive(trimprefix(each.value.secret_value, "nacl:")) : null
+
+  lifecycle {
+    replace_triggered_by = [github_repository.default[0].node_id]
+
+    precondition {
+      condition     = contains(keys(local.environments), each.value.environment)
+      error_message = "Environment secrets must reference an environment defined in environments"
+    }
+  }
+
+  depends_on = [
+    github_repository_environment.default
+  ]
 }
 
 locals {
@@ -262,6 +506,9 @@ locals {
   webhooks    = var.enabled ? var.webhooks : {}
   labels      = var.enabled ? var.labels : {}
   rulesets    = var.enabled ? var.rulesets : {}
+
+  deploy_keys_generated = { for k, v in local.deploy_keys : k => v.generate if v.generate != null }
+  deploy_keys_secrets   = { for k, v in local.deploy_keys_generated : k => v.actions_secret if v.actions_secret != null }
 }
 
 resource "github_actions_variable" "default" {
@@ -269,6 +516,11 @@ resource "github_actions_variable" "default" {
   repository    = join("", github_repository.default[*].name)
   variable_name = each.key
   value         = each.value
+
+  # Renames are applied in place, like the repository
+  lifecycle {
+    replace_triggered_by = [github_repository.default[0].node_id]
+  }
 }
 
 resource "github_actions_secret" "default" {
@@ -277,14 +529,35 @@ resource "github_actions_secret" "default" {
   secret_name     = each.key
   plaintext_value = !startswith(each.value, "nacl:") ? each.value : null
   encrypted_value = startswith(each.value, "nacl:") ? trimprefix(each.value, "nacl:") : null
+
+  lifecycle {
+    replace_triggered_by = [github_repository.default[0].node_id]
+  }
+}
+
+resource "tls_private_key" "deploy_keys" {
+  for_each  = local.deploy_keys_generated
+  algorithm = each.value.algorithm
+  rsa_bits  = each.value.algorithm == "RSA" ? each.value.rsa_bits : null
 }
 
 resource "github_repository_deploy_key" "default" {
   for_each   = local.deploy_keys
   repository = join("", github_repository.default[*].name)
   title      = each.value.title
-  key        = each.value.key
+  key        = each.value.generate != null ? trimspace(tls_private_key.deploy_keys[each.key].public_key_openssh) : each.value.key
   read_only  = each.value.read_only
+
+  lifecycle {
+    replace_triggered_by = [github_repository.default[0].node_id]
+  }
+}
+
+resource "github_actions_secret" "deploy_keys" {
+  for_each        = local.deploy_keys_secrets
+  repository      = each.value.repository
+  secret_name     = each.value.secret_name
+  plaintext_value = tls_private_key.deploy_keys[each.key].private_key_openssh
 }
 
 resource "github_repository_webhook" "default" {
@@ -299,6 +572,10 @@ resource "github_repository_webhook" "default" {
     insecure_ssl = each.value.insecure_ssl
     secret       = each.value.secret
   }
+
+  lifecycle {
+    replace_triggered_by = [github_repository.default[0].node_id]
+  }
 }
 
 resource "github_issue_label" "default" {
@@ -307,15 +584,39 @@ resource "github_issue_label" "default" {
   name        = each.key
   color       = trimprefix(each.value.color, "#")
   description = each.value.description
+
+  lifecycle {
+    replace_triggered_by = [github_repository.default[0].node_id]
+  }
+}
+
+locals {
+  teams = var.enabled ? var.teams : {}
+  users = var.enabled ? var.users : {}
+
+  collaborators_authoritative = var.collaborators_mode == "authoritative"
+
+  collaborators_builtin_permissions = ["pull", "triage", "push", "maintain", "admin"]
+
+  collaborators_custom_roles = toset([
+    for p in concat(values(local.teams), values(local.users)) : p if !contains(local.collaborators_builtin_permissions, p)
+  ])
+}
+
+# Fails the plan if a custom repository role does not exist in the organization
+data "github_organization_custom_role" "collaborators" {
+  for_each = local.collaborators_custom_roles
+
+  name = each.value
 }
 
 resource "github_repository_collaborators" "default" {
-  count = var.enabled && length(var.teams) > 0 || length(var.users) > 0 ? 1 : 0
+  count = local.collaborators_authoritative && (length(local.teams) > 0 || length(local.users) > 0) ? 1 : 0
 
   repository = join("", github_repository.default[*].name)
 
   dynamic "team" {
-    for_each = var.teams
+    for_each = local.teams
     content {
       permission = team.value
       team_id    = team.key
@@ -323,12 +624,52 @@ resource "github_repository_collaborators" "default" {
   }
 
   dynamic "user" {
-    for_each = var.users
+    for_each = local.users
     content {
       permission = user.value
       username   = user.key
     }
   }
+
+  lifecycle {
+    replace_triggered_by = [github_repository.default[0].node_id]
+  }
+
+  depends_on = [
+    data.github_organization_custom_role.collaborators
+  ]
+}
+
+resource "github_repository_collaborator" "default" {
+  for_each = local.collaborators_authoritative ? {} : local.users
+
+  repository = join("", github_repository.default[*].name)
+  username   = each.key
+  permission = each.value
+
+  lifecycle {
+    replace_triggered_by = [github_repository.default[0].node_id]
+  }
+
+  depends_on = [
+    data.github_organization_custom_role.collaborators
+  ]
+}
+
+resource "github_team_repository" "default" {
+  for_each = local.collaborators_authoritative ? {} : local.teams
+
+  repository = join("", github_repository.default[*].name)
+  team_id    = each.key
+  permission = each.value
+
+  lifecycle {
+    replace_triggered_by = [github_repository.default[0].node_id]
+  }
+
+  depends_on = [
+    data.github_organization_custom_role.collaborators
+  ]
 }
 
 locals {
@@ -343,6 +684,27 @@ locals {
     c.bypass_actors != null ? compact([for b in c.bypass_actors : b.actor_type == "Team" ? b.actor_id : null]) : []
   ])
 
+  ruleset_rules_roles = flatten([
+    for e, c in local.rulesets :
+    c.bypass_actors != null ? compact([
+      for b in c.bypass_actors :
+      b.actor_type == "RepositoryRole" && !contains(keys(local.organization_roles_map), b.actor_id) && !can(tonumber(b.actor_id)) ? b.actor_id : null
+    ]) : []
+  ])
+
+  ruleset_rules_integrations = flatten([
+    for e, c in local.rulesets : concat(
+      c.bypass_actors != null ? compact([
+        for b in c.bypass_actors :
+        b.actor_type == "Integration" && !can(tonumber(b.actor_id)) ? b.actor_id : null
+      ]) : [],
+      compact([
+        for r in try(c.rules.required_status_checks.required_check, []) :
+        r.integration_id != null && !can(tonumber(r.integration_id)) ? r.integration_id : null
+      ])
+    )
+  ])
+
   ruleset_conditions_refs_prefix = {
     "branch" = "refs/heads/"
     "tag"    = "refs/tags/"
@@ -355,6 +717,18 @@ data "github_team" "ruleset_rules_teams" {
   slug = each.value
 }
 
+data "github_organization_custom_role" "ruleset_rules_roles" {
+  for_each = toset(local.ruleset_rules_roles)
+
+  name = each.value
+}
+
+data "github_app" "ruleset_rules_integrations" {
+  for_each = toset(local.ruleset_rules_integrations)
+
+  slug = each.value
+}
+
 resource "github_repository_ruleset" "default" {
   for_each = local.rulesets
 
@@ -384,8 +758,11 @@ resource "github_repository_ruleset" "default" {
     content {
       bypass_mode = bypass_actors.value.bypass_mode
       actor_id = (bypass_actors.value.actor_type == "OrganizationAdmin" ? "0" :
-        bypass_actors.value.actor_type == "RepositoryRole" ? local.organization_roles_map[bypass_actors.value.actor_id] :
+        bypass_actors.value.actor_type == "DeployKey" ? "0" :
+        can(tonumber(bypass_actors.value.actor_id)) ? bypass_actors.value.actor_id :
+        bypass_actors.value.actor_type == "RepositoryRole" ? lookup(local.organization_roles_map, bypass_actors.value.actor_id, try(data.github_organization_custom_role.ruleset_rules_roles[bypass_actors.value.actor_id].id, null)) :
         bypass_actors.value.actor_type == "Team" ? data.github_team.ruleset_rules_teams[bypass_actors.value.actor_id].id :
+        bypass_actors.value.actor_type == "Integration" ? data.github_app.ruleset_rules_integrations[bypass_actors.value.actor_id].id :
       bypass_actors.value.actor_id)
       actor_type = bypass_actors.value.actor_type
     }
@@ -472,8 +849,9 @@ resource "github_repository_ruleset" "default" {
           dynamic "required_check" {
             for_each = required_status_checks.value.required_check
             content {
-              context        = required_check.value.context
-              integration_id = required_check.value.integration_id
+              context = required_check.value.context
+              integration_id = (required_check.value.integration_id == null || can(tonumber(required_check.value.integration_id)) ? required_check.value.integration_id :
+              data.github_app.ruleset_rules_integrations[required_check.value.integration_id].id)
             }
           }
           strict_required_status_checks_policy = required_status_checks.value.strict_required_status_checks_policy
@@ -507,7 +885,14 @@ resource "github_repository_ruleset" "default" {
       # }
     }
   }
+
+  # Renames are applied in place, like the repository
+  lifecycle {
+    replace_triggered_by = [github_repository.default[0].node_id]
+  }
+
   depends_on = [
-    github_repository_environment.default
+    github_repository_environment.default,
+    github_branch_default.default
   ]
 }
@@ -0,0 +1,75 @@
+locals {
+  # Base URL of scripts/github-api.sh, empty for the one of the GITHUB_BASE_URL environment variable
+  github_base_url = var.github_base_url != null ? var.github_base_url : ""
+
+  configuration = jsonencode({
+    for k, v in var.default_setup : k => v if v != null
+  })
+}
+
+# The GitHub provider does not manage code scanning default setup yet. It is applied with scripts/github-api.sh,
+# with the credentials of the environment.
+resource "terraform_data" "default" {
+  count = var.enabled ? 1 : 0
+
+  input = {
+    repository = var.repository
+    base_url   = local.github_base_url
+  }
+
+  triggers_replace = {
+    repository_node_id = var.repository_node_id
+    configuration      = local.configuration
+  }
+
+  provisioner "local-exec" {
+    command = "echo \"$CONFIGURATION\" | sh \"$SCRIPT\" PATCH \"repos/$REPOSITORY/code-scanning/default-setup\""
+    environment = {
+      SCRIPT        = "${path.module}/../../scripts/github-api.sh"
+      BASE_URL      = self.input.base_url
+      REPOSITORY    = self.input.repository
+      CONFIGURATION = self.triggers_replace.configuration
+    }
+  }
+
+  provisioner "local-exec" {
+    when    = destroy
+    command = "echo '{\"state\":\"not-configured\"}' | sh \"$SCRIPT\" PATCH \"repos/$REPOSITORY/code-scanning/default-setup\""
+    environment = {
+      SCRIPT     = "${path.module}/../../scripts/github-api.sh"
+      BASE_URL   = self.input.base_url
+      REPOSITORY = self.input.repository
+    }
+  }
+
+  lifecycle {
+    precondition {
+      condition     = var.visibility == "public" || var.advanced_security
+      error_message = "Code scanning requires advanced_security or code_security for private and internal repositories"
+    }
+  }
+}
+
+# Read after the default setup is applied. Changes made outside of Terraform fail the plan.
+data "external" "default" {
+  count = var.enabled ? 1 : 0
+
+  program = [
+    "env", "BASE_URL=${local.github_base_url}", "sh", "${path.module}/../../scripts/github-api.sh", "GET", "repos/${terraform_data.default[0].input.repository}/code-scanning/default-setup",
+    "{state: .state, query_suite: (.query_suite // \"\"), languages: (.languages // [] | sort | join(\",\")), runner_type: (.runner_type // \"\"), runner_label: (.runner_label // \"\")}",
+  ]
+
+  lifecycle {
+    postcondition {
+      condition = self.result.state == var.default_setup.state && (
+        self.result.state != "configured" || self.result.query_suite == var.default_setup.query_suite && self.result.runner_type == var.default_setup.runner_type &&
+        (var.default_setup.languages == null || self.result.languages == join(",", sort(coalesce(var.default_setup.languages, []))))
+      )
+      error_message = "Code scanning default setup differs from the configuration. Apply it again with -replace=<module address>.terraform_data.default[0]"
+    }
+  }
+
+  depends_on = [
+    terraform_data.default
+  ]
+}
@@ -0,0 +1,4 @@
+output "default_setup" {
+  description = "Code scanning default setup of the repository, as read from GitHub"
+  value       = one(data.external.default[*].result)
+}
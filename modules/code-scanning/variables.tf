@@ -0,0 +1,71 @@
+variable "enabled" {
+  description = "Enable or disable the code scanning default setup"
+  type        = bool
+  default     = true
+}
+
+variable "repository" {
+  description = "Full name of the repository, `owner/name`, such as the `full_name` output of the root module"
+  type        = string
+}
+
+variable "repository_node_id" {
+  description = "Node ID of the repository, such as the `node_id` output of the root module, so a recreated repository is configured again"
+  type        = string
+  default     = null
+}
+
+variable "visibility" {
+  description = "Visibility of the repository, private and internal repositories require advanced_security"
+  type        = string
+  default     = "public"
+}
+
+variable "advanced_security" {
+  description = "Whether GitHub Advanced Security or GitHub Code Security is enabled for the repository"
+  type        = bool
+  default     = false
+  nullable    = false
+}
+
+variable "default_setup" {
+  description = "CodeQL code scanning default setup"
+  type = object({
+    // configured, not-configured
+    state = optional(string, "configured")
+    // default, extended
+    query_suite = optional(string, "default")
+    // Detected by GitHub when null
+    languages = optional(list(string), null)
+    // standard, labeled
+    runner_type  = optional(string, "standard")
+    runner_label = optional(string, null)
+  })
+  default = {}
+
+  validation {
+    condition     = contains(["configured", "not-configured"], var.default_setup.state)
+    error_message = "Code scanning state must be configured or not-configured"
+  }
+
+  validation {
+    condition     = contains(["default", "extended"], var.default_setup.query_suite)
+    error_message = "Code scanning query suite must be default or extended"
+  }
+
+  validation {
+    condition     = contains(["standard", "labeled"], var.default_setup.runner_type)
+    error_message = "Code scanning runner type must be standard or labeled"
+  }
+
+  validation {
+    condition     = var.default_setup.runner_type != "labeled" || var.default_setup.runner_label != null
+    error_message = "Code scanning runner label must be specified for labeled runner type"
+  }
+}
+
+variable "github_base_url" {
+  description = "Base URL of the GitHub API, github.com when null. Set it to the `base_url` of the provider when it is not set by the `GITHUB_BASE_URL` environment variable."
+  type        = string
+  default     = null
+}
@@ -0,0 +1,105 @@
+locals {
+  # Base URL of scripts/github-api.sh, empty for the one of the GITHUB_BASE_URL environment variable
+  github_base_url = var.github_base_url != null ? var.github_base_url : ""
+
+  custom_protection_rules = var.enabled ? merge([
+    for e, c in var.custom_protection_rules :
+    { for v in c : format("%s-%s", e, v) => { "environment" : e, "integration" : v } }
+  ]...) : {}
+
+  integrations = distinct([
+    for k, v in local.custom_protection_rules : v.integration if !can(tonumber(v.integration))
+  ])
+}
+
+data "github_app" "default" {
+  for_each = toset(local.integrations)
+
+  slug = each.value
+}
+
+locals {
+  # App IDs of the custom protection rules, given by ID or looked up by slug
+  integration_ids = {
+    for k, v in local.custom_protection_rules :
+    k => can(tonumber(v.integration)) ? v.integration : data.github_app.default[v.integration].id
+  }
+}
+
+# The GitHub provider does not manage custom deployment protection rules yet. They are applied with
+# scripts/github-api.sh, with the credentials of the environment.
+resource "terraform_data" "default" {
+  for_each = local.custom_protection_rules
+
+  input = {
+    repository = var.repository
+    base_url   = local.github_base_url
+  }
+
+  triggers_replace = {
+    repository_id  = var.repository_node_id
+    environment    = each.value.environment
+    integration_id = local.integration_ids[each.key]
+  }
+
+  provisioner "local-exec" {
+    command = "printf '{\"integration_id\":%s}' \"$INTEGRATION_ID\" | sh \"$SCRIPT\" POST \"repos/$REPOSITORY/environments/$ENVIRONMENT/deployment_protection_rules\""
+    environment = {
+      SCRIPT         = "${path.module}/../../scripts/github-api.sh"
+      BASE_URL       = self.input.base_url
+      REPOSITORY     = self.input.repository
+      ENVIRONMENT    = urlencode(self.triggers_replace.environment)
+      INTEGRATION_ID = self.triggers_replace.integration_id
+    }
+  }
+
+  # Rules are deleted by their ID, looked up by the ID of their app
+  provisioner "local-exec" {
+    when    = destroy
+    command = <<-EOT
+      ids=$(sh "$SCRIPT" GET "repos/$REPOSITORY/environments/$ENVIRONMENT/deployment_protection_rules" ".custom_deployment_protection_rules[] | select(.app.id == $INTEGRATION_ID) | .id") || exit 1
+      for id in $ids; do
+        sh "$SCRIPT" DELETE "repos/$REPOSITORY/environments/$ENVIRONMENT/deployment_protection_rules/$id" || exit 1
+      done
+    EOT
+    environment = {
+      SCRIPT         = "${path.module}/../../scripts/github-api.sh"
+      BASE_URL       = self.input.base_url
+      REPOSITORY     = self.input.repository
+      ENVIRONMENT    = urlencode(self.triggers_replace.environment)
+      INTEGRATION_ID = self.triggers_replace.integration_id
+    }
+  }
+
+  lifecycle {
+    # Also orders the rules after the environments of the root module
+    precondition {
+      condition     = var.environments == null || contains(coalesce(var.environments, []), each.value.environment)
+      error_message = "Custom deployment protection rules are configured for an environment that is not an environment of the repository"
+    }
+  }
+}
+
+# Read after the rules are applied. Changes made outside of Terraform fail the plan.
+data "external" "default" {
+  for_each = toset([for k, v in local.custom_protection_rules : v.environment])
+
+  program = [
+    "env", "BASE_URL=${local.github_base_url}", "sh", "${path.module}/../../scripts/github-api.sh", "GET",
+    "repos/${var.repository}/environments/${urlencode(each.key)}/deployment_protection_rules",
+    "{integration_ids: ([.custom_deployment_protection_rules[].app.id | tostring] | sort | join(\",\"))}",
+  ]
+
+  lifecycle {
+    postcondition {
+      condition = self.result.integration_ids == join(",", sort([
+        for k, r in local.custom_protection_rules : local.integration_ids[k] if r.environment == each.key
+      ]))
+      error_message = "Custom deployment protection rules of the environment differ from the configuration. Apply them again with -replace=<module address>.terraform_data.default[\"<environment>-<app>\"]"
+    }
+  }
+
+  depends_on = [
+    terraform_data.default
+  ]
+}
@@ -0,0 +1,4 @@
+output "integration_ids" {
+  description = "IDs of the GitHub Apps of the custom deployment protection rules keyed by environment name, as read from GitHub"
+  value       = { for k, v in data.external.default : k => compact(split(",", v.result.integration_ids)) }
+}
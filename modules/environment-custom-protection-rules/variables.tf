@@ -0,0 +1,40 @@
+variable "enabled" {
+  description = "Enable or disable the custom deployment protection rules"
+  type        = bool
+  default     = true
+}
+
+variable "repository" {
+  description = "Full name of the repository, `owner/name`, such as the `full_name` output of the root module"
+  type        = string
+}
+
+variable "repository_node_id" {
+  description = "Node ID of the repository, such as the `node_id` output of the root module, so the rules of a recreated repository are applied again"
+  type        = string
+  default     = null
+}
+
+variable "environments" {
+  description = "Names of the environments of the repository, such as the `environments` output of the root module, so the rules are applied once the environments exist. Rules of other environments are refused"
+  type        = list(string)
+  default     = null
+}
+
+variable "custom_protection_rules" {
+  description = "Custom deployment protection rules keyed by environment name, as GitHub App slugs or IDs"
+  type        = map(list(string))
+  default     = {}
+  nullable    = false
+
+  validation {
+    condition     = alltrue([for k, v in var.custom_protection_rules : length(v) <= 6])
+    error_message = "Environment can not have more than 6 custom protection rules"
+  }
+}
+
+variable "github_base_url" {
+  description = "Base URL of the GitHub API, github.com when null. Set it to the `base_url` of the provider when it is not set by the `GITHUB_BASE_URL` environment variable."
+  type        = string
+  default     = null
+}
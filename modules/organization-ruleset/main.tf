@@ -0,0 +1,199 @@
+locals {
+  rulesets = var.enabled ? var.rulesets : {}
+
+  organization_roles_map = {
+    "maintain" = "2"
+    "write"    = "4"
+    "admin"    = "5"
+  }
+
+  ruleset_rules_teams = flatten([
+    for e, c in local.rulesets :
+    c.bypass_actors != null ? compact([for b in c.bypass_actors : b.actor_type == "Team" ? b.actor_id : null]) : []
+  ])
+
+  ruleset_rules_roles = flatten([
+    for e, c in local.rulesets :
+    c.bypass_actors != null ? compact([
+      for b in c.bypass_actors :
+      b.actor_type == "RepositoryRole" && !contains(keys(local.organization_roles_map), b.actor_id) && !can(tonumber(b.actor_id)) ? b.actor_id : null
+    ]) : []
+  ])
+
+  ruleset_rules_integrations = flatten([
+    for e, c in local.rulesets : concat(
+      c.bypass_actors != null ? compact([
+        for b in c.bypass_actors :
+        b.actor_type == "Integration" && !can(tonumber(b.actor_id)) ? b.actor_id : null
+      ]) : [],
+      compact([
+        for r in try(c.rules.required_status_checks.required_check, []) :
+        r.integration_id != null && !can(tonumber(r.integration_id)) ? r.integration_id : null
+      ])
+    )
+  ])
+
+  ruleset_conditions_refs_prefix = {
+    "branch" = "refs/heads/"
+    "tag"    = "refs/tags/"
+  }
+}
+
+data "github_team" "ruleset_rules_teams" {
+  for_each = toset(local.ruleset_rules_teams)
+
+  slug = each.value
+}
+
+data "github_organization_custom_role" "ruleset_rules_roles" {
+  for_each = toset(local.ruleset_rules_roles)
+
+  name = each.value
+}
+
+data "github_app" "ruleset_rules_integrations" {
+  for_each = toset(local.ruleset_rules_integrations)
+
+  slug = each.value
+}
+
+resource "github_organization_ruleset" "default" {
+  for_each = local.rulesets
+
+  name        = each.value.name
+  enforcement = each.value.enforcement
+  target      = each.value.target
+
+  conditions {
+    ref_name {
+      include = [
+        for c in each.value.conditions.ref_name.include :
+        startswith(c, local.ruleset_conditions_refs_prefix[each.value.target]) || c == "~DEFAULT_BRANCH" || c == "~ALL" ? c :
+        format("%s%s", local.ruleset_conditions_refs_prefix[each.value.target], c)
+      ]
+      exclude = [
+        for c in each.value.conditions.ref_name.exclude :
+        startswith(c, local.ruleset_conditions_refs_prefix[each.value.target]) ? c :
+        format("%s%s", local.ruleset_conditions_refs_prefix[each.value.target], c)
+      ]
+    }
+
+    repository_name {
+      include   = each.value.conditions.repository_name.include
+      exclude   = each.value.conditions.repository_name.exclude
+      protected = each.value.conditions.repository_name.protected
+    }
+  }
+
+  dynamic "bypass_actors" {
+    for_each = each.value.bypass_actors
+    content {
+      bypass_mode = bypass_actors.value.bypass_mode
+      actor_id = (bypass_actors.value.actor_type == "OrganizationAdmin" ? "0" :
+        bypass_actors.value.actor_type == "DeployKey" ? "0" :
+        can(tonumber(bypass_actors.value.actor_id)) ? bypass_actors.value.actor_id :
+        bypass_actors.value.actor_type == "RepositoryRole" ? lookup(local.organization_roles_map, bypass_actors.value.actor_id, try(data.github_organization_custom_role.ruleset_rules_roles[bypass_actors.value.actor_id].id, null)) :
+        bypass_actors.value.actor_type == "Team" ? data.github_team.ruleset_rules_teams[bypass_actors.value.actor_id].id :
+        bypass_actors.value.actor_type == "Integration" ? data.github_app.ruleset_rules_integrations[bypass_actors.value.actor_id].id :
+      bypass_actors.value.actor_id)
+      actor_type = bypass_actors.value.actor_type
+    }
+  }
+
+  dynamic "rules" {
+    for_each = each.value.rules != null ? [each.value.rules] : []
+    content {
+      creation         = rules.value.creation
+      deletion         = rules.value.deletion
+      non_fast_forward = rules.value.non_fast_forward
+
+      dynamic "branch_name_pattern" {
+        for_each = rules.value.branch_name_pattern != null ? [rules.value.branch_name_pattern] : []
+        content {
+          operator = branch_name_pattern.value.operator
+          pattern  = branch_name_pattern.value.pattern
+          negate   = branch_name_pattern.value.negate
+          name     = branch_name_pattern.value.name
+        }
+      }
+      dynamic "commit_author_email_pattern" {
+        for_each = rules.value.commit_author_email_pattern != null ? [rules.value.commit_author_email_pattern] : []
+        content {
+          operator = commit_author_email_pattern.value.operator
+          pattern  = commit_author_email_pattern.value.pattern
+          negate   = commit_author_email_pattern.value.negate
+          name     = commit_author_email_pattern.value.name
+        }
+      }
+      dynamic "commit_message_pattern" {
+        for_each = rules.value.commit_message_pattern != null ? [rules.value.commit_message_pattern] : []
+        content {
+          operator = commit_message_pattern.value.operator
+          pattern  = commit_message_pattern.value.pattern
+          negate   = commit_message_pattern.value.negate
+          name     = commit_message_pattern.value.name
+        }
+      }
+      dynamic "committer_email_pattern" {
+        for_each = rules.value.committer_email_pattern != null ? [rules.value.committer_email_pattern] : []
+        content {
+          operator = committer_email_pattern.value.operator
+          pattern  = committer_email_pattern.value.pattern
+          negate   = committer_email_pattern.value.negate
+          name     = committer_email_pattern.value.name
+        }
+      }
+
+      dynamic "pull_request" {
+        for_each = rules.value.pull_request != null ? [rules.value.pull_request] : []
+        content {
+          dismiss_stale_reviews_on_push     = pull_request.value.dismiss_stale_reviews_on_push
+          require_code_owner_review         = pull_request.value.require_code_owner_review
+          require_last_push_approval        = pull_request.value.require_last_push_approval
+          required_approving_review_count   = pull_request.value.required_approving_review_count
+          required_review_thread_resolution = pull_request.value.required_review_thread_resolution
+        }
+      }
+
+      dynamic "required_status_checks" {
+        for_each = rules.value.required_status_checks != null ? [rules.value.required_status_checks] : []
+        content {
+          dynamic "required_check" {
+            for_each = required_status_checks.value.required_check
+            content {
+              context = required_check.value.context
+              integration_id = (required_check.value.integration_id == null || can(tonumber(required_check.value.integration_id)) ? required_check.value.integration_id :
+              data.github_app.ruleset_rules_integrations[required_check.value.integration_id].id)
+            }
+          }
+          strict_required_status_checks_policy = required_status_checks.value.strict_required_status_checks_policy
+        }
+      }
+
+      dynamic "tag_name_pattern" {
+        for_each = rules.value.tag_name_pattern != null ? [rules.value.tag_name_pattern] : []
+        content {
+          operator = tag_name_pattern.value.operator
+          pattern  = tag_name_pattern.value.pattern
+          negate   = tag_name_pattern.value.negate
+          name     = tag_name_pattern.value.name
+        }
+      }
+
+      # Unsupported due to drift. https://github.com/integrations/terraform-provider-github/pull/2701
+      # dynamic "required_code_scanning" {
+      #   for_each = rules.value.required_code_scanning != null ? [rules.value.required_code_scanning] : []
+      #   content {
+      #     dynamic "required_code_scanning_tool" {
+      #       for_each = required_code_scanning.value.required_code_scanning_tool
+      #       content {
+      #         alerts_threshold          = required_code_scanning_tool.value.alerts_threshold
+      #         security_alerts_threshold = required_code_scanning_tool.value.security_alerts_threshold
+      #         tool                      = required_code_scanning_tool.value.tool
+      #       }
+      #     }
+      #   }
+      # }
+    }
+  }
+}
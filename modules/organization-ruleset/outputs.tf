@@ -0,0 +1,14 @@
+output "rulesets_etags" {
+  description = "Organization rulesets etags"
+  value       = { for k, v in github_organization_ruleset.default : k => v.etag }
+}
+
+output "rulesets_node_ids" {
+  description = "Organization rulesets node IDs"
+  value       = { for k, v in github_organization_ruleset.default : k => v.node_id }
+}
+
+output "rulesets_rules_ids" {
+  description = "Organization rulesets rules IDs"
+  value       = { for k, v in github_organization_ruleset.default : k => format("%d", v.ruleset_id) }
+}
@@ -0,0 +1,175 @@
+variable "enabled" {
+  description = "Enable or disable the organization rulesets creation"
+  type        = bool
+  default     = true
+}
+
+variable "rulesets" {
+  description = "A map of rulesets to configure for the organization"
+  type = map(object({
+    name = string
+    // disabled, active, evaluate
+    enforcement = string
+    // branch, tag
+    target = string
+    bypass_actors = optional(list(object({
+      // always, pull_request
+      bypass_mode = string
+      // RepositoryRole: maintain, write, admin, custom role name or ID
+      // Team: team slug
+      // Integration: GitHub App slug or ID
+      // OrganizationAdmin, DeployKey: not used
+      actor_id = optional(string, null)
+      // RepositoryRole, Team, Integration, OrganizationAdmin, DeployKey
+      actor_type = string
+    })), [])
+    conditions = object({
+      ref_name = object({
+        // Supports ~DEFAULT_BRANCH or ~ALL
+        include = optional(list(string), [])
+        exclude = optional(list(string), [])
+      })
+      repository_name = object({
+        // Supports ~ALL
+        include   = optional(list(string), [])
+        exclude   = optional(list(string), [])
+        protected = optional(bool, false)
+      })
+    })
+    rules = object({
+      branch_name_pattern = optional(object({
+        // starts_with, ends_with, contains, regex
+        operator = string
+        pattern  = string
+        name     = optional(string, null)
+        negate   = optional(bool, false)
+      }), null),
+      commit_author_email_pattern = optional(object({
+        // starts_with, ends_with, contains, regex
+        operator = string
+        pattern  = string
+        name     = optional(string, null)
+        negate   = optional(bool, false)
+      }), null),
+      creation         = optional(bool, false),
+      deletion         = optional(bool, false),
+      non_fast_forward = optional(bool, false),
+      commit_message_pattern = optional(object({
+        // starts_with, ends_with, contains, regex
+        operator = string
+        pattern  = string
+        name     = optional(string, null)
+        negate   = optional(bool, false)
+      }), null),
+      committer_email_pattern = optional(object({
+        // starts_with, ends_with, contains, regex
+        operator = string
+        pattern  = string
+        name     = optional(string, null)
+        negate   = optional(bool, false)
+      }), null),
+      pull_request = optional(object({
+        dismiss_stale_reviews_on_push     = optional(bool, false)
+        require_code_owner_review         = optional(bool, false)
+        require_last_push_approval        = optional(bool, false)
+        required_approving_review_count   = optional(number, 0)
+        required_review_thread_resolution = optional(bool, false)
+      }), null),
+      required_status_checks = optional(object({
+        required_check = list(object({
+          context = string
+          // GitHub App slug or ID
+          integration_id = optional(string, null)
+        }))
+        strict_required_status_checks_policy = optional(bool, false)
+      }), null),
+      tag_name_pattern = optional(object({
+        // starts_with, ends_with, contains, regex
+        operator = string
+        pattern  = string
+        name     = optional(string, null)
+        negate   = optional(bool, false)
+      }), null),
+      // Unsupported due to drift.
+      // https://github.com/integrations/terraform-provider-github/pull/2701
+      # required_code_scanning = optional(object({
+      #   required_code_scanning_tool = list(object({
+      #     // none, errors, errors_and_warnings, all
+      #     alerts_threshold          = string
+      #     // none, critical, high_or_higher, medium_or_higher, all
+      #     security_alerts_threshold = string
+      #     tool                      = string
+      #   }))
+      # }), null),
+    }),
+  }))
+  default = {}
+
+  validation {
+    condition     = alltrue([for k, v in var.rulesets : can(regex("^[a-zA-Z0-9_]+$", k))])
+    error_message = "Ruleset names must be alphanumeric and underscores only, can not start with a number"
+  }
+
+  validation {
+    condition     = alltrue([for k, v in var.rulesets : contains(["disabled", "active", "evaluate"], v.enforcement)])
+    error_message = "Ruleset enforcement must be disabled, active, or evaluate"
+  }
+
+  validation {
+    condition     = alltrue([for k, v in var.rulesets : contains(["branch", "tag"], v.target)])
+    error_message = "Ruleset target must be branch or tag"
+  }
+
+  validation {
+    condition     = alltrue([for k, v in var.rulesets : alltrue([for b in v.bypass_actors : contains(["always", "pull_request"], b.bypass_mode)])])
+    error_message = "Ruleset bypass mode must be always or pull_request"
+  }
+
+  validation {
+    condition     = alltrue([for k, v in var.rulesets : alltrue([for b in v.bypass_actors : contains(["RepositoryRole", "Team", "Integration", "OrganizationAdmin", "DeployKey"], b.actor_type)])])
+    error_message = "Ruleset actor type must be RepositoryRole, Team, Integration, OrganizationAdmin or DeployKey"
+  }
+
+  validation {
+    condition     = alltrue([for k, v in var.rulesets : alltrue([for b in v.bypass_actors : contains(["OrganizationAdmin", "DeployKey"], b.actor_type) || b.actor_id != null])])
+    error_message = "Ruleset actor ID must be specified for RepositoryRole, Team and Integration actor types"
+  }
+
+  validation {
+    condition     = alltrue([for k, v in var.rulesets : try(contains(["starts_with", "ends_with", "contains", "regex"], v.rules.branch_name_pattern.operator), true)])
+    error_message = "Ruleset branch name pattern operator must be starts_with, ends_with, contains or regex"
+  }
+
+  validation {
+    condition     = alltrue([for k, v in var.rulesets : v.target == "branch" || try(v.rules.branch_name_pattern == null, true)])
+    error_message = "Ruleset branch name pattern can be specified only for branch rulesets"
+  }
+
+  validation {
+    condition     = alltrue([for k, v in var.rulesets : try(contains(["starts_with", "ends_with", "contains", "equals", "regex"], v.rules.commit_author_email_pattern.operator), true)])
+    error_message = "Ruleset commit author email pattern operator must be starts_with, ends_with, contains, equals or regex"
+  }
+
+  validation {
+    condition     = alltrue([for k, v in var.rulesets : try(contains(["starts_with", "ends_with", "contains", "equals", "regex"], v.rules.commit_message_pattern.operator), true)])
+    error_message = "Ruleset commit message pattern operator must be starts_with, ends_with, contains, equals or regex"
+  }
+
+  validation {
+    condition     = alltrue([for k, v in var.rulesets : try(contains(["starts_with", "ends_with", "contains", "equals", "regex"], v.rules.committer_email_pattern.operator), true)])
+    error_message = "Ruleset committer email pattern operator must be starts_with, ends_with, contains, equals or regex"
+  }
+
+
+
+
+  validation {
+    condition     = alltrue([for k, v in var.rulesets : try(contains(["starts_with", "ends_with", "contains", "regex"], v.rules.tag_name_pattern.operator), true)])
+    error_message = "Ruleset branch name pattern operator must be starts_with, ends_with, contains or regex"
+  }
+
+  validation {
+    condition     = alltrue([for k, v in var.rulesets : v.target == "tag" || try(v.rules.tag_name_pattern == null, true)])
+    error_message = "Ruleset tag name pattern can be specified only for tag rulesets"
+  }
+}
@@ -0,0 +1,176 @@
+locals {
+  catalog = var.catalog_file != null ? yamldecode(file(var.catalog_file)) : {}
+
+  catalog_defaults     = try(local.catalog.defaults, null) != null ? local.catalog.defaults : {}
+  catalog_repositories = try(local.catalog.repositories, null) != null ? local.catalog.repositories : {}
+
+  repositories_keys = var.enabled ? toset(concat(keys(local.catalog_repositories), keys(var.repositories))) : toset([])
+}
+
+// Precedence from lowest to highest: catalog defaults, var.defaults, catalog repository, var.repositories
+module "deepmerge" {
+  source  = "cloudposse/config/yaml//modules/deepmerge"
+  version = "1.0.2"
+
+  for_each = local.repositories_keys
+
+  maps = [
+    local.catalog_defaults,
+    var.defaults,
+    try(local.catalog_repositories[each.key], null) != null ? local.catalog_repositories[each.key] : {},
+    try(var.repositories[each.key], null) != null ? var.repositories[each.key] : {},
+  ]
+}
+
+locals {
+  repositories = { for k, v in module.deepmerge : k => merge({ name = k }, v.merged) }
+
+  // Attributes of the repository definitions, passed to the root module by module.repository
+  repository_attributes = [
+    "name",
+    "enabled",
+    "description",
+    "visibility",
+    "template",
+    "fork",
+    "recreate_on_template_change",
+    "homepage_url",
+    "topics",
+    "archived",
+    "archive_on_destroy",
+    "deletion_protection",
+    "allow_destroy",
+    "is_template",
+    "has_discussions",
+    "has_downloads",
+    "has_issues",
+    "has_projects",
+    "has_wiki",
+    "allow_squash_merge",
+    "allow_merge_commit",
+    "allow_rebase_merge",
+    "squash_merge_commit_title",
+    "squash_merge_commit_message",
+    "allow_auto_merge",
+    "merge_commit_title",
+    "merge_commit_message",
+    "allow_update_branch",
+    "delete_branch_on_merge",
+    "auto_init",
+    "gitignore_template",
+    "license_template",
+    "web_commit_signoff_required",
+    "ignore_vulnerability_alerts_during_read",
+    "autolink_references",
+    "default_branch",
+    "enable_vulnerability_alerts",
+    "security_and_analysis",
+    "github_base_url",
+    "enable_dependabot_security_updates",
+    "manage_default_branch",
+    "rename_default_branch",
+    "custom_properties",
+    "environments",
+    "environment_secrets",
+    "variables",
+    "secrets",
+    "deploy_keys",
+    "webhooks",
+    "labels",
+    "teams",
+    "users",
+    "rulesets",
+    "collaborators_mode",
+  ]
+
+  // Misspelled or unsupported attributes, which would otherwise be silently ignored
+  unknown_attributes = {
+    for k, v in local.repositories : k => sort(setsubtract(keys(v), local.repository_attributes))
+    if length(setsubtract(keys(v), local.repository_attributes)) > 0
+  }
+}
+
+module "repository" {
+  source = "../../"
+
+  for_each = local.repositories
+
+  enabled = try(each.value.enabled, true)
+
+  name        = each.value.name
+  description = try(each.value.description, null)
+  visibility  = try(each.value.visibility, "public")
+
+  template = try(each.value.template, null)
+  fork     = try(each.value.fork, null)
+
+  recreate_on_template_change = try(each.value.recreate_on_template_change, false)
+
+  homepage_url = try(each.value.homepage_url, null)
+  topics       = try(each.value.topics, [])
+
+  archived           = try(each.value.archived, false)
+  archive_on_destroy = try(each.value.archive_on_destroy, false)
+
+  deletion_protection = try(each.value.deletion_protection, false)
+  allow_destroy       = try(each.value.allow_destroy, false)
+
+  is_template = try(each.value.is_template, false)
+
+  has_discussions = try(each.value.has_discussions, false)
+  has_downloads   = try(each.value.has_downloads, false)
+  has_issues      = try(each.value.has_issues, false)
+  has_projects    = try(each.value.has_projects, false)
+  has_wiki        = try(each.value.has_wiki, false)
+
+  allow_squash_merge = try(each.value.allow_squash_merge, true)
+  allow_merge_commit = try(each.value.allow_merge_commit, true)
+  allow_rebase_merge = try(each.value.allow_rebase_merge, true)
+
+  squash_merge_commit_title   = try(each.value.squash_merge_commit_title, "PR_TITLE")
+  squash_merge_commit_message = try(each.value.squash_merge_commit_message, "COMMIT_MESSAGES")
+
+  allow_auto_merge = try(each.value.allow_auto_merge, false)
+
+  merge_commit_title   = try(each.value.merge_commit_title, "PR_TITLE")
+  merge_commit_message = try(each.value.merge_commit_message, "PR_BODY")
+
+  allow_update_branch    = try(each.value.allow_update_branch, false)
+  delete_branch_on_merge = try(each.value.delete_branch_on_merge, false)
+
+  auto_init          = try(each.value.auto_init, false)
+  gitignore_template = try(each.value.gitignore_template, null)
+  license_template   = try(each.value.license_template, null)
+
+  web_commit_signoff_required = try(each.value.web_commit_signoff_required, false)
+
+  ignore_vulnerability_alerts_during_read = try(each.value.ignore_vulnerability_alerts_during_read, false)
+
+  autolink_references         = try(each.value.autolink_references, {})
+  default_branch              = try(each.value.default_branch, "main")
+  enable_vulnerability_alerts = try(each.value.enable_vulnerability_alerts, true)
+  security_and_analysis       = try(each.value.security_and_analysis, null)
+
+  github_base_url = try(each.value.github_base_url, null)
+
+  enable_dependabot_security_updates = try(each.value.enable_dependabot_security_updates, null)
+
+  manage_default_branch = try(each.value.manage_default_branch, null)
+  rename_default_branch = try(each.value.rename_default_branch, false)
+
+  custom_properties = try(each.value.custom_properties, {})
+  environments      = try(each.value.environments, {})
+
+  environment_secrets = try(each.value.environment_secrets, {})
+
+  variables   = try(each.value.variables, {})
+  secrets     = try(each.value.secrets, {})
+  deploy_keys = try(each.value.deploy_keys, {})
+  webhooks    = try(each.value.webhooks, {})
+  labels      = try(each.value.labels, {})
+  teams       = try(each.value.teams, {})
+  users       = try(each.value.users, {})
+  rulesets    = try(each.value.rulesets, {})
+
+  collaborators_mode = try(each.value.collaborators_mode, "authoritative")
+}
@@ -0,0 +1,86 @@
+output "repositories" {
+  description = "Merged repository definitions keyed by repository"
+  value       = local.repositories
+  sensitive   = true
+
+  precondition {
+    condition     = length(local.unknown_attributes) == 0
+    error_message = "Repository definitions have unknown attributes: ${jsonencode(local.unknown_attributes)}"
+  }
+}
+
+output "full_name" {
+  description = "Full names of the created repositories"
+  value       = { for k, v in module.repository : k => v.full_name }
+}
+
+output "html_url" {
+  description = "HTML URLs of the created repositories"
+  value       = { for k, v in module.repository : k => v.html_url }
+}
+
+output "ssh_clone_url" {
+  description = "SSH clone URLs of the created repositories"
+  value       = { for k, v in module.repository : k => v.ssh_clone_url }
+}
+
+output "http_clone_url" {
+  description = "HTTP clone URLs of the created repositories"
+  value       = { for k, v in module.repository : k => v.http_clone_url }
+}
+
+output "git_clone_url" {
+  description = "Git clone URLs of the created repositories"
+  value       = { for k, v in module.repository : k => v.git_clone_url }
+}
+
+output "svn_url" {
+  description = "SVN URLs of the created repositories"
+  value       = { for k, v in module.repository : k => v.svn_url }
+}
+
+output "node_id" {
+  description = "Node IDs of the created repositories"
+  value       = { for k, v in module.repository : k => v.node_id }
+}
+
+output "repo_id" {
+  description = "Repository IDs of the created repositories"
+  value       = { for k, v in module.repository : k => v.repo_id }
+}
+
+output "primary_language" {
+  description = "Primary languages of the created repositories"
+  value       = { for k, v in module.repository : k => v.primary_language }
+}
+
+output "webhooks_urls" {
+  description = "Webhooks URLs keyed by repository"
+  value       = { for k, v in module.repository : k => v.webhooks_urls }
+}
+
+output "deploy_keys_private_keys" {
+  description = "Private keys of the generated deploy keys keyed by repository"
+  value       = { for k, v in module.repository : k => v.deploy_keys_private_keys }
+  sensitive   = true
+}
+
+output "collaborators_invitation_ids" {
+  description = "Collaborators invitation IDs keyed by repository"
+  value       = { for k, v in module.repository : k => v.collaborators_invitation_ids }
+}
+
+output "rulesets_etags" {
+  description = "Rulesets etags keyed by repository"
+  value       = { for k, v in module.repository : k => v.rulesets_etags }
+}
+
+output "rulesets_node_ids" {
+  description = "Rulesets node IDs keyed by repository"
+  value       = { for k, v in module.repository : k => v.rulesets_node_ids }
+}
+
+output "rulesets_rules_ids" {
+  description = "Rulesets rules IDs keyed by repository"
+  value       = { for k, v in module.repository : k => v.rulesets_rules_ids }
+}
@@ -0,0 +1,25 @@
+variable "enabled" {
+  description = "Enable or disable the repositories creation"
+  type        = bool
+  default     = true
+}
+
+variable "catalog_file" {
+  description = "Path to a YAML catalog file with `defaults` and `repositories` keys"
+  type        = string
+  default     = null
+}
+
+variable "defaults" {
+  description = "Organization-wide defaults deep-merged into every repository definition. Takes precedence over the catalog defaults."
+  type        = any
+  default     = {}
+  nullable    = false
+}
+
+variable "repositories" {
+  description = "A map of repository definitions keyed by repository name. Accepts the same attributes as the root module and takes precedence over the catalog repositories."
+  type        = any
+  default     = {}
+  nullable    = false
+}
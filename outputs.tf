@@ -43,14 +43,28 @@ output "primary_language" {
   value       = join("", github_repository.default[*].primary_language)
 }
 
+output "environments" {
+  description = "Names of the environments of the repository"
+  value       = [for k, v in github_repository_environment.default : v.environment]
+}
+
 output "webhooks_urls" {
   description = "Webhooks URLs"
   value       = { for k, v in github_repository_webhook.default : k => v.url }
 }
 
+output "deploy_keys_private_keys" {
+  description = "Private keys of the generated deploy keys in OpenSSH format"
+  value       = { for k, v in tls_private_key.deploy_keys : k => v.private_key_openssh }
+  sensitive   = true
+}
+
 output "collaborators_invitation_ids" {
   description = "Collaborators invitation IDs"
-  value       = var.enabled ? github_repository_collaborators.default[*].invitation_ids : []
+  value       = concat(
+    github_repository_collaborators.default[*].invitation_ids,
+    length(github_repository_collaborator.default) > 0 ? [{ for k, v in github_repository_collaborator.default : k => v.invitation_id }] : []
+  )
 }
 
 output "rulesets_etags" {
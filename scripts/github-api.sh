@@ -0,0 +1,93 @@
+#!/bin/sh
+# Calls the GitHub REST API for the settings that the GitHub provider does not manage yet.
+#
+# Usage: github-api.sh METHOD PATH [FILTER]
+#
+# The request body is read from stdin for POST, PATCH and PUT requests. The response is printed, filtered by the jq
+# FILTER when set. Any error fails the call, with the status and the response of the API.
+#
+# As for the provider, the API is the GitHub Enterprise Server of GITHUB_BASE_URL, or github.com, unless BASE_URL is
+# set. Requests are authenticated as the GitHub App of GITHUB_APP_ID, GITHUB_APP_INSTALLATION_ID and
+# GITHUB_APP_PEM_FILE when set, or with GITHUB_TOKEN otherwise.
+#
+# Requires curl and jq, and openssl for GitHub App authentication.
+set -eu
+
+fail() {
+  echo "github-api.sh: $*" >&2
+  exit 1
+}
+
+require() {
+  command -v "$1" >/dev/null 2>&1 || fail "$1 is required to manage the settings that the GitHub provider does not manage yet"
+}
+
+[ $# -ge 2 ] || fail "usage: github-api.sh METHOD PATH [FILTER]"
+method=$1
+path=$2
+filter=${3:-}
+
+require curl
+require jq
+
+# API URL of the base URL, as set by the provider for GitHub Enterprise Server
+base_url=${BASE_URL:-${GITHUB_BASE_URL:-}}
+case "$base_url" in
+  "" | https://api.github.com*) api_url=https://api.github.com/ ;;
+  */api/v3/) api_url=$base_url ;;
+  */api/v3) api_url=$base_url/ ;;
+  */) api_url=${base_url}api/v3/ ;;
+  *) api_url=$base_url/api/v3/ ;;
+esac
+
+response=$(mktemp)
+key=$(mktemp)
+trap 'rm -f "$response" "$key"' EXIT
+
+# request METHOD PATH AUTHORIZATION writes the response to METHOD PATH in the response file, with the body of stdin for
+# POST, PATCH and PUT requests
+request() {
+  body=
+  case "$1" in
+    POST | PATCH | PUT) body=@- ;;
+  esac
+  status=$(curl --silent --show-error --output "$response" --write-out '%{http_code}' --request "$1" \
+    --header "Accept: application/vnd.github+json" \
+    --header "Authorization: $3" \
+    --header "X-GitHub-Api-Version: 2022-11-28" \
+    ${body:+--data-binary} ${body:+"$body"} \
+    "$api_url$2") || fail "$1 $2: request failed"
+  case "$status" in
+    2??) ;;
+    *) fail "$1 $2: $status: $(cat "$response")" ;;
+  esac
+}
+
+base64url() {
+  openssl base64 -A | tr '+/' '-_' | tr -d '='
+}
+
+if [ -n "${GITHUB_APP_ID:-}${GITHUB_APP_INSTALLATION_ID:-}${GITHUB_APP_PEM_FILE:-}" ]; then
+  [ -n "${GITHUB_APP_ID:-}" ] && [ -n "${GITHUB_APP_INSTALLATION_ID:-}" ] && [ -n "${GITHUB_APP_PEM_FILE:-}" ] ||
+    fail "GITHUB_APP_ID, GITHUB_APP_INSTALLATION_ID and GITHUB_APP_PEM_FILE must all be set"
+  require openssl
+
+  # As for the provider, escaped newlines of the private key are replaced with newlines
+  printf '%s\n' "$GITHUB_APP_PEM_FILE" | awk '{ gsub(/\\n/, "\n"); print }' >"$key"
+  now=$(date +%s)
+  jwt=$(printf '{"alg":"RS256","typ":"JWT"}' | base64url).$(printf '{"iss":"%s","iat":%d,"exp":%d}' "$GITHUB_APP_ID" $((now - 60)) $((now + 300)) | base64url)
+  jwt=$jwt.$(printf '%s' "$jwt" | openssl dgst -sha256 -sign "$key" | base64url)
+  request POST "app/installations/$GITHUB_APP_INSTALLATION_ID/access_tokens" "Bearer $jwt" </dev/null
+  token=$(jq -r .token "$response")
+elif [ -n "${GITHUB_TOKEN:-}" ]; then
+  token=$GITHUB_TOKEN
+else
+  fail "GITHUB_TOKEN, or GITHUB_APP_ID, GITHUB_APP_INSTALLATION_ID and GITHUB_APP_PEM_FILE, must be set as for the GitHub provider"
+fi
+
+request "$method" "$path" "Bearer $token"
+if [ -n "$filter" ]; then
+  jq --compact-output "$filter" "$response"
+else
+  cat "$response"
+fi
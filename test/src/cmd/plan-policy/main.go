@@ -0,0 +1,113 @@
+// Command plan-policy evaluates the policies of the organization, and rules written in CEL, on a plan of the module.
+//
+//   terraform plan -out plan.tfplan
+//   terraform show -json plan.tfplan > plan.json
+//   plan-policy [-rules rules.yaml]... [-no-builtin] [-format text|json] plan.json
+//
+// Rules files hold lists of rules in YAML or JSON, in the format of internal/policy/builtin.yaml. It exits with
+// status 1 when any rule fails.
+package main
+
+import (
+  "encoding/json"
+  "flag"
+  "fmt"
+  "os"
+  "strings"
+
+  "github.com/cloudposse/terraform-example-module/internal/policy"
+)
+
+type files []string
+
+func (f *files) String() string {
+  return strings.Join(*f, ",")
+}
+
+func (f *files) Set(value string) error {
+  *f = append(*f, value)
+  return nil
+}
+
+func main() {
+  var rules files
+  flag.Var(&rules, "rules", "file of rules to evaluate, may be repeated")
+  noBuiltin := flag.Bool("no-builtin", false, "do not evaluate the builtin rules")
+  format := flag.String("format", "text", "output format, text or json")
+  flag.Parse()
+
+  if flag.NArg() != 1 {
+    fmt.Fprintln(os.Stderr, "usage: plan-policy [-rules file]... [-no-builtin] [-format text|json] plan.json")
+    os.Exit(2)
+  }
+
+  failed, err := run(flag.Arg(0), rules, !*noBuiltin, *format)
+  if err != nil {
+    fmt.Fprintln(os.Stderr, "error:", err)
+    os.Exit(2)
+  }
+  if failed {
+    os.Exit(1)
+  }
+}
+
+func run(file string, rulesFiles []string, builtin bool, format string) (bool, error) {
+  if format != "text" && format != "json" {
+    return false, fmt.Errorf("unsupported format %q, must be text or json", format)
+  }
+
+  var rules []policy.Rule
+  if builtin {
+    rules = policy.Builtin()
+  }
+  for _, rulesFile := range rulesFiles {
+    src, err := os.ReadFile(rulesFile)
+    if err != nil {
+      return false, err
+    }
+    r, err := policy.ParseRules(src)
+    if err != nil {
+      return false, fmt.Errorf("%s: %w", rulesFile, err)
+    }
+    rules = append(rules, r...)
+  }
+
+  engine, err := policy.NewEngine(rules)
+  if err != nil {
+    return false, err
+  }
+
+  src, err := os.ReadFile(file)
+  if err != nil {
+    return false, err
+  }
+  plan, err := policy.ParsePlan(src)
+  if err != nil {
+    return false, fmt.Errorf("%s: %w", file, err)
+  }
+
+  results := engine.Evaluate(plan)
+  if format == "json" {
+    if results == nil {
+      results = []policy.Result{}
+    }
+    out, err := json.MarshalIndent(results, "", "  ")
+    if err != nil {
+      return false, err
+    }
+    fmt.Println(string(out))
+    return policy.Failed(results), nil
+  }
+
+  for _, result := range results {
+    switch {
+    case result.Error != "":
+      fmt.Printf("FAIL %s %s: %s\n", result.Rule, result.Address, result.Error)
+    case !result.Passed:
+      fmt.Printf("FAIL %s %s: %s\n", result.Rule, result.Address, result.Description)
+    default:
+      fmt.Printf("PASS %s %s\n", result.Rule, result.Address)
+    }
+  }
+  return policy.Failed(results), nil
+}
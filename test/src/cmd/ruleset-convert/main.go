@@ -0,0 +1,137 @@
+// Command ruleset-convert converts rulesets exported from the GitHub UI to the rulesets input of the module.
+//
+//   ruleset-convert [-owner org] [-format hcl|json] export.json...
+//   ruleset-convert -reverse [-owner org] [-out dir] rulesets.tfvars
+//
+// With -owner, team slugs, custom repository roles and GitHub App slugs of the organization are resolved
+// using the GITHUB_TOKEN environment variable, or as the GitHub App set by the GITHUB_APP_ID,
+// GITHUB_APP_INSTALLATION_ID and GITHUB_APP_PEM_FILE environment variables of the Terraform provider.
+// Otherwise only built-in repository roles are resolved and other actors are kept as IDs.
+// GITHUB_BASE_URL and GITHUB_UPLOAD_URL select a GitHub Enterprise Server.
+//
+// Rules the module does not support are reported on stderr and left out of the result.
+// With -reverse, the rulesets variable of a .tfvars or .tfvars.json file is written as one importable
+// JSON file per ruleset.
+package main
+
+import (
+  "context"
+  "encoding/json"
+  "flag"
+  "fmt"
+  "os"
+  "path/filepath"
+
+  "github.com/cloudposse/terraform-example-module/internal/appauth"
+  "github.com/cloudposse/terraform-example-module/internal/rulesets"
+  "github.com/cloudposse/terraform-example-module/internal/tfvars"
+)
+
+func main() {
+  reverse := flag.Bool("reverse", false, "convert module input to importable ruleset JSON")
+  format := flag.String("format", tfvars.FormatHCL, "output format of the module input, hcl or json")
+  owner := flag.String("owner", "", "organization used to resolve teams, custom roles and GitHub Apps")
+  out := flag.String("out", ".", "directory for the ruleset JSON files written with -reverse")
+  flag.Parse()
+
+  if flag.NArg() == 0 {
+    fmt.Fprintln(os.Stderr, "usage: ruleset-convert [-reverse] [-owner org] [-format hcl|json] [-out dir] file...")
+    os.Exit(2)
+  }
+
+  actors := rulesets.NewActors()
+  if *owner != "" {
+    client, err := appauth.ClientFromEnv()
+    if err != nil {
+      fail(err)
+    }
+    if err := actors.Load(context.Background(), client, *owner); err != nil {
+      fail(fmt.Errorf("loading actors of %s: %w", *owner, err))
+    }
+  }
+
+  var err error
+  if *reverse {
+    err = fromModule(flag.Args(), actors, *out)
+  } else {
+    err = toModule(flag.Args(), actors, *format)
+  }
+  if err != nil {
+    fail(err)
+  }
+}
+
+func toModule(files []string, actors *rulesets.Actors, format string) error {
+  var exports []rulesets.Export
+  for _, file := range files {
+    src, err := os.ReadFile(file)
+    if err != nil {
+      return err
+    }
+    parsed, err := rulesets.ParseExports(src)
+    if err != nil {
+      return fmt.Errorf("%s: %w", file, err)
+    }
+    exports = append(exports, parsed...)
+  }
+
+  converted, warnings := rulesets.ToModule(exports, actors)
+  for _, warning := range warnings {
+    fmt.Fprintln(os.Stderr, "warning:", warning)
+  }
+
+  src, err := tfvars.Encode(map[string]interface{}{"rulesets": converted}, format)
+  if err != nil {
+    return err
+  }
+  _, err = os.Stdout.Write(src)
+  return err
+}
+
+func fromModule(files []string, actors *rulesets.Actors, out string) error {
+  input := make(map[string]rulesets.Ruleset)
+  for _, file := range files {
+    src, err := os.ReadFile(file)
+    if err != nil {
+      return err
+    }
+    vars, err := tfvars.Decode(file, src)
+    if err != nil {
+      return err
+    }
+    raw, ok := vars["rulesets"]
+    if !ok {
+      return fmt.Errorf("%s: no rulesets variable", file)
+    }
+    var parsed map[string]rulesets.Ruleset
+    if err := json.Unmarshal(raw, &parsed); err != nil {
+      return fmt.Errorf("%s: %w", file, err)
+    }
+    for key, ruleset := range parsed {
+      input[key] = ruleset
+    }
+  }
+
+  exports, err := rulesets.FromModule(input, actors)
+  if err != nil {
+    return err
+  }
+
+  for key, export := range exports {
+    src, err := json.MarshalIndent(export, "", "  ")
+    if err != nil {
+      return err
+    }
+    path := filepath.Join(out, key+".json")
+    if err := os.WriteFile(path, append(src, '\n'), 0o644); err != nil {
+      return err
+    }
+    fmt.Fprintln(os.Stderr, "wrote", path)
+  }
+  return nil
+}
+
+func fail(err error) {
+  fmt.Fprintln(os.Stderr, "error:", err)
+  os.Exit(1)
+}
@@ -0,0 +1,68 @@
+// Command schema-gen generates a JSON Schema for the inputs of a module from the variables it declares.
+//
+//   schema-gen [-title title] [-out inputs.schema.json] ../..
+//
+// The schema validates .tfvars.json files, and YAML files holding the same inputs such as the vars of atmos
+// components, before Terraform runs.
+package main
+
+import (
+  "encoding/json"
+  "flag"
+  "fmt"
+  "os"
+  "path/filepath"
+
+  "github.com/cloudposse/terraform-example-module/internal/schema"
+)
+
+func main() {
+  title := flag.String("title", "", "title of the schema, defaults to the name of the module folder")
+  out := flag.String("out", "", "file to write the schema to, defaults to stdout")
+  flag.Parse()
+
+  if flag.NArg() != 1 {
+    fmt.Fprintln(os.Stderr, "usage: schema-gen [-title title] [-out file] module-dir")
+    os.Exit(2)
+  }
+
+  if err := run(flag.Arg(0), *title, *out); err != nil {
+    fmt.Fprintln(os.Stderr, "error:", err)
+    os.Exit(1)
+  }
+}
+
+func run(dir, title, out string) error {
+  files, err := filepath.Glob(filepath.Join(dir, "*.tf"))
+  if err != nil {
+    return err
+  }
+  if len(files) == 0 {
+    return fmt.Errorf("no .tf files in %s", dir)
+  }
+
+  if title == "" {
+    abs, err := filepath.Abs(dir)
+    if err != nil {
+      return err
+    }
+    title = filepath.Base(abs)
+  }
+
+  s, err := schema.Generate(title, files)
+  if err != nil {
+    return err
+  }
+
+  src, err := json.MarshalIndent(s, "", "  ")
+  if err != nil {
+    return err
+  }
+  src = append(src, '\n')
+
+  if out == "" {
+    _, err = os.Stdout.Write(src)
+    return err
+  }
+  return os.WriteFile(out, src, 0o644)
+}
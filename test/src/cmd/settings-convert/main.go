@@ -0,0 +1,80 @@
+// Command settings-convert converts a .github/settings.yml file of the probot settings app to inputs of the module.
+//
+//   settings-convert [-owner org] [-format hcl|json] .github/settings.yml
+//
+// With -owner, environment reviewers, bypass actors and GitHub Apps are resolved to the names accepted by the
+// module using the GITHUB_TOKEN environment variable, or as the GitHub App set by the GITHUB_APP_ID,
+// GITHUB_APP_INSTALLATION_ID and GITHUB_APP_PEM_FILE environment variables of the Terraform provider.
+// Otherwise unknown reviewers are left out and other actors are kept as IDs.
+// GITHUB_BASE_URL and GITHUB_UPLOAD_URL select a GitHub Enterprise Server.
+//
+// Branch protections are converted to rulesets. Settings the module does not support are reported on stderr
+// and left out of the result.
+package main
+
+import (
+  "context"
+  "flag"
+  "fmt"
+  "os"
+
+  "github.com/cloudposse/terraform-example-module/internal/appauth"
+  "github.com/cloudposse/terraform-example-module/internal/rulesets"
+  "github.com/cloudposse/terraform-example-module/internal/settings"
+  "github.com/cloudposse/terraform-example-module/internal/tfvars"
+)
+
+func main() {
+  format := flag.String("format", tfvars.FormatHCL, "output format, hcl or json")
+  owner := flag.String("owner", "", "organization used to resolve teams, users, custom roles and GitHub Apps")
+  flag.Parse()
+
+  if flag.NArg() != 1 {
+    fmt.Fprintln(os.Stderr, "usage: settings-convert [-owner org] [-format hcl|json] settings.yml")
+    os.Exit(2)
+  }
+
+  if err := run(flag.Arg(0), *owner, *format); err != nil {
+    fmt.Fprintln(os.Stderr, "error:", err)
+    os.Exit(1)
+  }
+}
+
+func run(file, owner, format string) error {
+  src, err := os.ReadFile(file)
+  if err != nil {
+    return err
+  }
+  s, err := settings.Parse(src)
+  if err != nil {
+    return fmt.Errorf("%s: %w", file, err)
+  }
+
+  names := settings.NewNames()
+  actors := rulesets.NewActors()
+  if owner != "" {
+    ctx := context.Background()
+    client, err := appauth.ClientFromEnv()
+    if err != nil {
+      return err
+    }
+    if err := names.Load(ctx, client, owner, s); err != nil {
+      return fmt.Errorf("loading reviewers of %s: %w", owner, err)
+    }
+    if err := actors.Load(ctx, client, owner); err != nil {
+      return fmt.Errorf("loading actors of %s: %w", owner, err)
+    }
+  }
+
+  vars, warnings := settings.Convert(s, names, actors)
+  for _, warning := range warnings {
+    fmt.Fprintln(os.Stderr, "warning:", warning)
+  }
+
+  out, err := tfvars.Encode(vars, format)
+  if err != nil {
+    return err
+  }
+  _, err = os.Stdout.Write(out)
+  return err
+}
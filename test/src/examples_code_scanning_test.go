@@ -0,0 +1,140 @@
+package test
+
+import (
+  "os"
+  "testing"
+  "context"
+  "errors"
+  "fmt"
+
+  "github.com/gruntwork-io/terratest/modules/terraform"
+  "github.com/stretchr/testify/assert"
+  "github.com/google/go-github/v73/github"
+)
+
+// Test the Terraform module in examples/code-scanning using Terratest.
+func TestExamplesCodeScanning(t *testing.T) {
+  t.Parallel()
+  vcr := startVCR(t)
+  randID := vcr.randID
+
+  rootFolder := "../../"
+  terraformFolderRelativeToRoot := "examples/code-scanning"
+  varFiles := []string{"fixtures.us-east-2.tfvars"}
+
+  tempTestFolder := vcr.copyTerraformFolderToTemp(rootFolder, terraformFolderRelativeToRoot)
+
+  repositoryName := fmt.Sprintf("terraform-github-repository-test-%s", randID)
+
+  terraformOptions := &terraform.Options{
+    // The path to where our Terraform code is located
+    TerraformDir: tempTestFolder,
+    Upgrade:      true,
+    EnvVars:      vcr.envVars,
+    // Variables to pass to our Terraform code using -var-file options
+    VarFiles: varFiles,
+    Vars: map[string]interface{}{
+      "enabled":    true,
+      "name": repositoryName,
+      "visibility": "public",
+      // Code scanning needs source code in a supported language
+      "fork": map[string]interface{}{
+        "source_owner": "cloudposse",
+        "source_repo": "terraform-example-module",
+      },
+      "default_setup": map[string]interface{}{
+        "query_suite": "extended",
+        "languages": []string{"go", "actions"},
+      },
+    },
+  }
+
+  // At the end of the test, run `terraform destroy` to clean up any resources that were created
+  defer cleanup(t, terraformOptions, tempTestFolder)
+
+  // This will run `terraform init` and `terraform apply` and fail the test if there are any errors
+  terraform.InitAndApply(t, terraformOptions)
+
+  client := vcr.client()
+
+  defaultSetup, _, err := read(func(ctx context.Context) (*github.DefaultSetupConfiguration, *github.Response, error) {
+    return client.CodeScanning.GetDefaultSetupConfiguration(ctx, owner, repositoryName)
+  })
+  assert.NoError(t, err)
+  assert.Equal(t, "configured", defaultSetup.GetState())
+  assert.Equal(t, "extended", defaultSetup.GetQuerySuite())
+  assert.ElementsMatch(t, []string{"go", "actions"}, defaultSetup.Languages)
+
+  // Not exposed by go-github yet, read from the raw default setup payload
+  var runner struct {
+    RunnerType string `json:"runner_type"`
+  }
+  err = readRaw(client, fmt.Sprintf("repos/%s/%s/code-scanning/default-setup", owner, repositoryName), &runner)
+  assert.NoError(t, err)
+  assert.Equal(t, "standard", runner.RunnerType)
+
+  // This will run `terraform apply` a second time and fail the test if there are any errors
+  terraform.Apply(t, terraformOptions)
+
+  // Changes made outside of Terraform fail the plan
+  _, _, err = client.CodeScanning.UpdateDefaultSetupConfiguration(context.Background(), owner, repositoryName, &github.UpdateDefaultSetupConfigurationOptions{State: "not-configured"})
+  var accepted *github.AcceptedError
+  if !errors.As(err, &accepted) {
+    assert.NoError(t, err)
+  }
+  _, _, err = readUntil(func(ctx context.Context) (*github.DefaultSetupConfiguration, *github.Response, error) {
+    return client.CodeScanning.GetDefaultSetupConfiguration(ctx, owner, repositoryName)
+  }, func(defaultSetup *github.DefaultSetupConfiguration, err error) bool { return err == nil && defaultSetup.GetState() == "not-configured" })
+  assert.NoError(t, err)
+
+  output, err := terraform.PlanE(t, terraformOptions)
+  assert.Error(t, err)
+  assert.Contains(t, output, "Code scanning default setup differs from the configuration")
+
+  // Replacing the default setup applies it again
+  terraformOptions.PlanFilePath = ""
+  terraform.RunTerraformCommand(t, terraformOptions, terraform.FormatArgs(terraformOptions, "apply", "-input=false", "-auto-approve", "-replace=module.example.terraform_data.default[0]")...)
+
+  defaultSetup, _, err = readUntil(func(ctx context.Context) (*github.DefaultSetupConfiguration, *github.Response, error) {
+    return client.CodeScanning.GetDefaultSetupConfiguration(ctx, owner, repositoryName)
+  }, func(defaultSetup *github.DefaultSetupConfiguration, err error) bool { return err == nil && defaultSetup.GetState() == "configured" })
+  assert.NoError(t, err)
+  assert.Equal(t, "extended", defaultSetup.GetQuerySuite())
+}
+
+func TestExamplesCodeScanningWithoutAdvancedSecurity(t *testing.T) {
+  t.Parallel()
+  vcr := startVCR(t)
+  randID := vcr.randID
+
+  rootFolder := "../../"
+  terraformFolderRelativeToRoot := "examples/code-scanning"
+  varFiles := []string{"fixtures.us-east-2.tfvars"}
+
+  tempTestFolder := vcr.copyTerraformFolderToTemp(rootFolder, terraformFolderRelativeToRoot)
+  defer os.RemoveAll(tempTestFolder)
+
+  repositoryName := fmt.Sprintf("terraform-github-repository-test-%s", randID)
+
+  terraformOptions := &terraform.Options{
+    // The path to where our Terraform code is located
+    TerraformDir: tempTestFolder,
+    Upgrade:      true,
+    EnvVars:      vcr.envVars,
+    // Variables to pass to our Terraform code using -var-file options
+    VarFiles: varFiles,
+    Vars: map[string]interface{}{
+      "enabled":    true,
+      "name": repositoryName,
+      "visibility": "private",
+      "default_setup": map[string]interface{}{
+        "query_suite": "default",
+      },
+    },
+  }
+
+  // The plan should be refused before any API call is made
+  output, err := terraform.InitAndPlanE(t, terraformOptions)
+  assert.Error(t, err)
+  assert.Contains(t, output, "Code scanning requires advanced_security or code_security for private and internal repositories")
+}
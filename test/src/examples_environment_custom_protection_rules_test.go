@@ -0,0 +1,113 @@
+package test
+
+import (
+  "os"
+  "testing"
+  "context"
+  "fmt"
+
+  "github.com/gruntwork-io/terratest/modules/terraform"
+  "github.com/stretchr/testify/assert"
+  "github.com/google/go-github/v73/github"
+)
+
+// Test the Terraform module in examples/environment-custom-protection-rules using Terratest.
+func TestExamplesEnvironmentCustomProtectionRules(t *testing.T) {
+  t.Parallel()
+  vcr := startVCR(t)
+  randID := vcr.randID
+
+  // Custom deployment protection rules require a GitHub App installed in the organization
+  // that subscribes to deployment_protection_rule events
+  appSlug := os.Getenv("GITHUB_DEPLOYMENT_PROTECTION_APP")
+  if appSlug == "" {
+    t.Skip("GITHUB_DEPLOYMENT_PROTECTION_APP is not set")
+  }
+
+  rootFolder := "../../"
+  terraformFolderRelativeToRoot := "examples/environment-custom-protection-rules"
+  varFiles := []string{"fixtures.us-east-2.tfvars"}
+
+  tempTestFolder := vcr.copyTerraformFolderToTemp(rootFolder, terraformFolderRelativeToRoot)
+
+  repositoryName := fmt.Sprintf("terraform-github-repository-test-%s", randID)
+
+  terraformOptions := &terraform.Options{
+    // The path to where our Terraform code is located
+    TerraformDir: tempTestFolder,
+    Upgrade:      true,
+    EnvVars:      vcr.envVars,
+    // Variables to pass to our Terraform code using -var-file options
+    VarFiles: varFiles,
+    Vars: map[string]interface{}{
+      "enabled":    true,
+      "name": repositoryName,
+      "custom_protection_rules": map[string]interface{}{
+        "production": []string{appSlug},
+      },
+    },
+  }
+
+  // At the end of the test, run `terraform destroy` to clean up any resources that were created
+  defer cleanup(t, terraformOptions, tempTestFolder)
+
+  // This will run `terraform init` and `terraform apply` and fail the test if there are any errors
+  terraform.InitAndApply(t, terraformOptions)
+
+  client := vcr.client()
+
+  app, _, err := read(func(ctx context.Context) (*github.App, *github.Response, error) {
+    return client.Apps.Get(ctx, appSlug)
+  })
+  assert.NoError(t, err)
+
+  env, _, err := read(func(ctx context.Context) (*github.Environment, *github.Response, error) {
+    return client.Repositories.GetEnvironment(ctx, owner, repositoryName, "production")
+  })
+  assert.NoError(t, err)
+  assert.NotNil(t, env)
+
+  customRules := []*github.ProtectionRule{}
+  for _, rule := range env.ProtectionRules {
+    if rule.GetType() == "custom" {
+      customRules = append(customRules, rule)
+    }
+  }
+  assert.Equal(t, 1, len(customRules))
+
+  rules, _, err := readUntil(func(ctx context.Context) (*github.ListDeploymentProtectionRuleResponse, *github.Response, error) {
+    return client.Repositories.GetAllDeploymentProtectionRules(ctx, owner, repositoryName, "production")
+  }, func(rules *github.ListDeploymentProtectionRuleResponse, err error) bool { return err == nil && rules.GetTotalCount() == 1 })
+  assert.NoError(t, err)
+  assert.Equal(t, 1, rules.GetTotalCount())
+  assert.Equal(t, app.GetID(), rules.ProtectionRules[0].GetApp().GetID())
+  assert.Equal(t, true, rules.ProtectionRules[0].GetEnabled())
+
+  // This will run `terraform apply` a second time and fail the test if there are any errors
+  terraform.Apply(t, terraformOptions)
+
+  // Should complete successfully without creating or changing any resources
+  results := terraform.Plan(t, terraformOptions)
+  assert.Contains(t, results, "No changes.")
+
+  // Rules removed outside of Terraform fail the plan
+  _, err = client.Repositories.DisableCustomDeploymentProtectionRule(context.Background(), owner, repositoryName, "production", rules.ProtectionRules[0].GetID())
+  assert.NoError(t, err)
+  _, _, err = readUntil(func(ctx context.Context) (*github.ListDeploymentProtectionRuleResponse, *github.Response, error) {
+    return client.Repositories.GetAllDeploymentProtectionRules(ctx, owner, repositoryName, "production")
+  }, func(rules *github.ListDeploymentProtectionRuleResponse, err error) bool { return err == nil && rules.GetTotalCount() == 0 })
+  assert.NoError(t, err)
+
+  results, err = terraform.PlanE(t, terraformOptions)
+  assert.Error(t, err)
+  assert.Contains(t, results, "Custom deployment protection rules of the environment differ from the configuration")
+
+  // Replacing the rule applies it again
+  terraformOptions.PlanFilePath = ""
+  terraform.RunTerraformCommand(t, terraformOptions, terraform.FormatArgs(terraformOptions, "apply", "-input=false", "-auto-approve", fmt.Sprintf("-replace=module.example.terraform_data.default[\"production-%s\"]", appSlug))...)
+
+  _, _, err = readUntil(func(ctx context.Context) (*github.ListDeploymentProtectionRuleResponse, *github.Response, error) {
+    return client.Repositories.GetAllDeploymentProtectionRules(ctx, owner, repositoryName, "production")
+  }, func(rules *github.ListDeploymentProtectionRuleResponse, err error) bool { return err == nil && rules.GetTotalCount() == 1 })
+  assert.NoError(t, err)
+}
@@ -0,0 +1,167 @@
+package test
+
+import (
+  "strconv"
+  "testing"
+  "context"
+  "fmt"
+
+  "github.com/gruntwork-io/terratest/modules/terraform"
+  "github.com/stretchr/testify/assert"
+  "github.com/google/go-github/v73/github"
+)
+
+// Test the Terraform module in examples/organization-ruleset using Terratest.
+func TestExamplesOrganizationRuleset(t *testing.T) {
+  t.Parallel()
+  vcr := startVCR(t)
+  randID := vcr.randID
+
+  rootFolder := "../../"
+  terraformFolderRelativeToRoot := "examples/organization-ruleset"
+  varFiles := []string{"fixtures.us-east-2.tfvars"}
+
+  tempTestFolder := vcr.copyTerraformFolderToTemp(rootFolder, terraformFolderRelativeToRoot)
+
+  repositoryName := fmt.Sprintf("terraform-github-repository-test-%s", randID)
+  rulesetNameByName := fmt.Sprintf("terraform-github-repository-test-%s-name", randID)
+  rulesetNameByPattern := fmt.Sprintf("terraform-github-repository-test-%s-pattern", randID)
+
+  terraformOptions := &terraform.Options{
+    // The path to where our Terraform code is located
+    TerraformDir: tempTestFolder,
+    Upgrade:      true,
+    EnvVars:      vcr.envVars,
+    // Variables to pass to our Terraform code using -var-file options
+    VarFiles: varFiles,
+    Vars: map[string]interface{}{
+      "enabled": true,
+      "rulesets": map[string]interface{}{
+        "by_name": map[string]interface{}{
+          "name": rulesetNameByName,
+          "enforcement": "active",
+          "target": "branch",
+          "conditions": map[string]interface{}{
+            "ref_name": map[string]interface{}{
+              "include": []string{"~DEFAULT_BRANCH"},
+            },
+            "repository_name": map[string]interface{}{
+              "include": []string{repositoryName},
+            },
+          },
+          "bypass_actors": []map[string]interface{}{
+            {
+              "bypass_mode": "always",
+              "actor_type": "Team",
+              "actor_id": "test-team",
+            },
+            {
+              "bypass_mode": "pull_request",
+              "actor_type": "RepositoryRole",
+              "actor_id": "maintain",
+            },
+            {
+              "bypass_mode": "always",
+              "actor_type": "Integration",
+              "actor_id": "github-actions",
+            },
+          },
+          "rules": map[string]interface{}{
+            "deletion": true,
+            "pull_request": map[string]interface{}{
+              "required_approving_review_count": 1,
+            },
+          },
+        },
+        "by_pattern": map[string]interface{}{
+          "name": rulesetNameByPattern,
+          // Disabled so the ruleset does not affect other repositories of the organization
+          "enforcement": "disabled",
+          "target": "branch",
+          "conditions": map[string]interface{}{
+            "ref_name": map[string]interface{}{
+              "include": []string{"~DEFAULT_BRANCH"},
+            },
+            "repository_name": map[string]interface{}{
+              "include": []string{"terraform-github-repository-test-*"},
+              "exclude": []string{repositoryName},
+              "protected": true,
+            },
+          },
+          "rules": map[string]interface{}{
+            "non_fast_forward": true,
+          },
+        },
+      },
+    },
+  }
+
+  // At the end of the test, run `terraform destroy` to clean up any resources that were created
+  defer cleanup(t, terraformOptions, tempTestFolder)
+
+  // This will run `terraform init` and `terraform apply` and fail the test if there are any errors
+  terraform.InitAndApply(t, terraformOptions)
+
+  client := vcr.client()
+
+  rulesetsIds := terraform.OutputMap(t, terraformOptions, "rulesets_rules_ids")
+  assert.Equal(t, 2, len(rulesetsIds))
+
+  rulesetID, err := strconv.ParseInt(rulesetsIds["by_name"], 10, 64)
+  assert.NoError(t, err)
+
+  ruleset, _, err := client.Organizations.GetRepositoryRuleset(context.Background(), owner, rulesetID)
+  assert.NoError(t, err)
+  assert.NotNil(t, ruleset)
+
+  assert.Equal(t, rulesetNameByName, ruleset.Name)
+  assert.EqualValues(t, "active", ruleset.Enforcement)
+  assert.EqualValues(t, "Organization", *ruleset.SourceType)
+  assert.EqualValues(t, "branch", *ruleset.Target)
+  assert.EqualValues(t, []string{"~DEFAULT_BRANCH"}, ruleset.GetConditions().RefName.Include)
+  assert.EqualValues(t, []string{repositoryName}, ruleset.GetConditions().RepositoryName.Include)
+  assert.Nil(t, ruleset.GetConditions().RepositoryProperty)
+
+  team, _, err := client.Teams.GetTeamBySlug(context.Background(), owner, "test-team")
+  assert.NoError(t, err)
+
+  app, _, err := client.Apps.Get(context.Background(), "github-actions")
+  assert.NoError(t, err)
+
+  assert.Equal(t, 3, len(ruleset.BypassActors))
+
+  bypassActors := make(map[string]*github.BypassActor)
+  for _, actor := range ruleset.BypassActors {
+    bypassActors[string(*actor.GetActorType())] = actor
+  }
+
+  assert.Equal(t, team.GetID(), bypassActors["Team"].GetActorID())
+  assert.Equal(t, int64(2), bypassActors["RepositoryRole"].GetActorID())
+  assert.EqualValues(t, "pull_request", *bypassActors["RepositoryRole"].GetBypassMode())
+  assert.Equal(t, app.GetID(), bypassActors["Integration"].GetActorID())
+
+  assert.NotNil(t, ruleset.GetRules().GetDeletion())
+  assert.EqualValues(t, 1, ruleset.GetRules().GetPullRequest().RequiredApprovingReviewCount)
+
+  rulesetID, err = strconv.ParseInt(rulesetsIds["by_pattern"], 10, 64)
+  assert.NoError(t, err)
+
+  ruleset, _, err = client.Organizations.GetRepositoryRuleset(context.Background(), owner, rulesetID)
+  assert.NoError(t, err)
+  assert.NotNil(t, ruleset)
+
+  assert.Equal(t, rulesetNameByPattern, ruleset.Name)
+  assert.EqualValues(t, "disabled", ruleset.Enforcement)
+  assert.EqualValues(t, []string{"terraform-github-repository-test-*"}, ruleset.GetConditions().RepositoryName.Include)
+  assert.EqualValues(t, []string{repositoryName}, ruleset.GetConditions().RepositoryName.Exclude)
+  assert.Equal(t, true, ruleset.GetConditions().RepositoryName.GetProtected())
+  assert.Nil(t, ruleset.GetConditions().RepositoryProperty)
+  assert.NotNil(t, ruleset.GetRules().GetNonFastForward())
+
+  // This will run `terraform apply` a second time and fail the test if there are any errors
+  terraform.Apply(t, terraformOptions)
+
+  // Should complete successfully without creating or changing any resources
+  results := terraform.Plan(t, terraformOptions)
+  assert.Contains(t, results, "No changes.")
+}
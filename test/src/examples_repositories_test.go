@@ -0,0 +1,250 @@
+package test
+
+import (
+  "testing"
+  "context"
+  "fmt"
+  "os"
+
+  "github.com/gruntwork-io/terratest/modules/terraform"
+  "github.com/stretchr/testify/assert"
+)
+
+// Test the Terraform module in examples/repositories using Terratest.
+func TestExamplesRepositories(t *testing.T) {
+  t.Parallel()
+  vcr := startVCR(t)
+  randID := vcr.randID
+
+  rootFolder := "../../"
+  terraformFolderRelativeToRoot := "examples/repositories"
+  varFiles := []string{"fixtures.us-east-2.tfvars"}
+
+  tempTestFolder := vcr.copyTerraformFolderToTemp(rootFolder, terraformFolderRelativeToRoot)
+
+  repositoryNameA := fmt.Sprintf("terraform-github-repository-%s-a", randID)
+  repositoryNameB := fmt.Sprintf("terraform-github-repository-%s-b", randID)
+
+  terraformOptions := &terraform.Options{
+    // The path to where our Terraform code is located
+    TerraformDir: tempTestFolder,
+    Upgrade:      true,
+    EnvVars:      vcr.envVars,
+    // Variables to pass to our Terraform code using -var-file options
+    VarFiles: varFiles,
+    Vars: map[string]interface{}{
+      "enabled": true,
+      "repositories": map[string]interface{}{
+        "service-a": map[string]interface{}{
+          "name": repositoryNameA,
+        },
+        "service-b": map[string]interface{}{
+          "name": repositoryNameB,
+        },
+      },
+    },
+  }
+
+  // At the end of the test, run `terraform destroy` to clean up any resources that were created
+  defer cleanup(t, terraformOptions, tempTestFolder)
+
+  // This will run `terraform init` and `terraform apply` and fail the test if there are any errors
+  terraform.InitAndApply(t, terraformOptions)
+
+  client := vcr.client()
+
+  repoA, _, err := client.Repositories.Get(context.Background(), owner, repositoryNameA)
+  assert.NoError(t, err)
+  assert.Equal(t, "Service A", repoA.GetDescription())
+  assert.Equal(t, "public", repoA.GetVisibility())
+  assert.Equal(t, true, repoA.GetHasIssues())
+  assert.Equal(t, true, repoA.GetDeleteBranchOnMerge())
+
+  topics, _, err := client.Repositories.ListAllTopics(context.Background(), owner, repositoryNameA)
+  assert.NoError(t, err)
+  assert.ElementsMatch(t, []string{"service"}, topics)
+
+  label, _, err := client.Issues.GetLabel(context.Background(), owner, repositoryNameA, "defect")
+  assert.NoError(t, err)
+  assert.Equal(t, "a73a4a", label.GetColor())
+
+  label, _, err = client.Issues.GetLabel(context.Background(), owner, repositoryNameA, "feature")
+  assert.NoError(t, err)
+  assert.Equal(t, "336699", label.GetColor())
+
+  repoB, _, err := client.Repositories.Get(context.Background(), owner, repositoryNameB)
+  assert.NoError(t, err)
+  assert.Equal(t, "Service B", repoB.GetDescription())
+  assert.Equal(t, false, repoB.GetHasIssues())
+  assert.Equal(t, true, repoB.GetDeleteBranchOnMerge())
+
+  topics, _, err = client.Repositories.ListAllTopics(context.Background(), owner, repositoryNameB)
+  assert.NoError(t, err)
+  assert.ElementsMatch(t, []string{"terraform", "github"}, topics)
+
+  label, _, err = client.Issues.GetLabel(context.Background(), owner, repositoryNameB, "defect")
+  assert.NoError(t, err)
+  assert.Equal(t, "ff0000", label.GetColor())
+  assert.Equal(t, "🐛 An issue with the system", label.GetDescription())
+
+  // This will run `terraform apply` a second time and fail the test if there are any errors
+  terraform.Apply(t, terraformOptions)
+
+  fullNames := terraform.OutputMap(t, terraformOptions, "full_name")
+  htmlUrls := terraform.OutputMap(t, terraformOptions, "html_url")
+  repoIds := terraform.OutputMap(t, terraformOptions, "repo_id")
+
+  assert.Equal(t, 2, len(fullNames))
+  assert.Equal(t, fmt.Sprintf("%s/%s", owner, repositoryNameA), fullNames["service-a"])
+  assert.Equal(t, fmt.Sprintf("%s/%s", owner, repositoryNameB), fullNames["service-b"])
+  assert.Equal(t, fmt.Sprintf("https://%s/%s/%s", webHost(), owner, repositoryNameA), htmlUrls["service-a"])
+  assert.Equal(t, fmt.Sprintf("%d", repoA.GetID()), repoIds["service-a"])
+  assert.Equal(t, fmt.Sprintf("%d", repoB.GetID()), repoIds["service-b"])
+}
+
+// Test the merge semantics of modules/repositories without creating any repository.
+func TestExamplesRepositoriesMerge(t *testing.T) {
+  t.Parallel()
+  vcr := startVCR(t)
+  randID := vcr.randID
+
+  rootFolder := "../../"
+  terraformFolderRelativeToRoot := "examples/repositories"
+  varFiles := []string{"fixtures.us-east-2.tfvars"}
+
+  tempTestFolder := vcr.copyTerraformFolderToTemp(rootFolder, terraformFolderRelativeToRoot)
+
+  repositoryNameA := fmt.Sprintf("terraform-github-repository-%s-a", randID)
+
+  terraformOptions := &terraform.Options{
+    // The path to where our Terraform code is located
+    TerraformDir: tempTestFolder,
+    Upgrade:      true,
+    EnvVars:      vcr.envVars,
+    // Variables to pass to our Terraform code using -var-file options
+    VarFiles: varFiles,
+    Vars: map[string]interface{}{
+      "enabled": true,
+      "defaults": map[string]interface{}{
+        "enabled":  false,
+        "has_wiki": true,
+        "labels": map[string]interface{}{
+          "question": map[string]interface{}{
+            "color":       "#d876e3",
+            "description": "Further information is requested",
+          },
+        },
+      },
+      "repositories": map[string]interface{}{
+        "service-a": map[string]interface{}{
+          "name":       repositoryNameA,
+          "has_issues": false,
+        },
+        "service-c": map[string]interface{}{
+          "description": "Service C",
+          "topics":      []string{"extra"},
+        },
+      },
+    },
+  }
+
+  // At the end of the test, run `terraform destroy` to clean up any resources that were created
+  defer cleanup(t, terraformOptions, tempTestFolder)
+
+  // This will run `terraform init` and `terraform apply` and fail the test if there are any errors
+  results := terraform.InitAndApply(t, terraformOptions)
+
+  // Should complete successfully without creating or changing any resources
+  assert.Contains(t, results, "Resources: 0 added, 0 changed, 0 destroyed.")
+
+  repositories := map[string]map[string]interface{}{}
+  terraform.OutputStruct(t, terraformOptions, "repositories", &repositories)
+
+  // Catalog and input repositories are combined
+  assert.Equal(t, 3, len(repositories))
+
+  serviceA := repositories["service-a"]
+  // Input repository overrides catalog repository
+  assert.Equal(t, repositoryNameA, serviceA["name"])
+  assert.Equal(t, false, serviceA["has_issues"])
+  // Catalog repository is kept where input repository does not override it
+  assert.Equal(t, "Service A", serviceA["description"])
+  // Input defaults override catalog defaults
+  assert.Equal(t, false, serviceA["enabled"])
+  assert.Equal(t, true, serviceA["has_wiki"])
+  assert.Equal(t, true, serviceA["delete_branch_on_merge"])
+  // Lists are replaced, not appended
+  assert.ElementsMatch(t, []interface{}{"service"}, serviceA["topics"])
+  // Maps are deep-merged
+  labelsA, ok := serviceA["labels"].(map[string]interface{})
+  assert.True(t, ok)
+  assert.ElementsMatch(t, []string{"defect", "feature", "question"}, mapKeys(labelsA))
+
+  serviceB := repositories["service-b"]
+  assert.Equal(t, "service-b", serviceB["name"])
+  assert.Equal(t, false, serviceB["has_issues"])
+  assert.ElementsMatch(t, []interface{}{"terraform", "github"}, serviceB["topics"])
+  labelsB, ok := serviceB["labels"].(map[string]interface{})
+  assert.True(t, ok)
+  defectB, ok := labelsB["defect"].(map[string]interface{})
+  assert.True(t, ok)
+  // Nested attributes are overridden one by one
+  assert.Equal(t, "#ff0000", defectB["color"])
+  assert.Equal(t, "🐛 An issue with the system", defectB["description"])
+
+  serviceC := repositories["service-c"]
+  assert.Equal(t, "service-c", serviceC["name"])
+  assert.Equal(t, "Service C", serviceC["description"])
+  assert.Equal(t, true, serviceC["has_issues"])
+  assert.ElementsMatch(t, []interface{}{"extra"}, serviceC["topics"])
+
+  fullNames := terraform.OutputMap(t, terraformOptions, "full_name")
+  assert.Equal(t, 3, len(fullNames))
+  assert.Equal(t, "", fullNames["service-a"])
+}
+
+// Test that misspelled attributes of the repository definitions are refused.
+func TestExamplesRepositoriesUnknownAttributes(t *testing.T) {
+  t.Parallel()
+  vcr := startVCR(t)
+
+  rootFolder := "../../"
+  terraformFolderRelativeToRoot := "examples/repositories"
+  varFiles := []string{"fixtures.us-east-2.tfvars"}
+
+  tempTestFolder := vcr.copyTerraformFolderToTemp(rootFolder, terraformFolderRelativeToRoot)
+  defer os.RemoveAll(tempTestFolder)
+
+  terraformOptions := &terraform.Options{
+    // The path to where our Terraform code is located
+    TerraformDir: tempTestFolder,
+    Upgrade:      true,
+    EnvVars:      vcr.envVars,
+    // Variables to pass to our Terraform code using -var-file options
+    VarFiles: varFiles,
+    Vars: map[string]interface{}{
+      "enabled": true,
+      "defaults": map[string]interface{}{
+        "enabled": false,
+      },
+      "repositories": map[string]interface{}{
+        "service-a": map[string]interface{}{
+          "has_isues": false,
+        },
+      },
+    },
+  }
+
+  // The plan should be refused before any API call is made
+  output, err := terraform.InitAndPlanE(t, terraformOptions)
+  assert.Error(t, err)
+  assert.Contains(t, output, `Repository definitions have unknown attributes: {"service-a":["has_isues"]}`)
+}
+
+func mapKeys(m map[string]interface{}) []string {
+  keys := make([]string, 0, len(m))
+  for k := range m {
+    keys = append(keys, k)
+  }
+  return keys
+}
@@ -0,0 +1,152 @@
+package test
+
+import (
+  "fmt"
+  "os"
+  "path/filepath"
+  "strings"
+  "testing"
+
+  "github.com/cloudposse/terraform-example-module/internal/appauth"
+  "github.com/cloudposse/terraform-example-module/internal/vcr"
+  "github.com/google/go-github/v73/github"
+  "github.com/gruntwork-io/terratest/modules/random"
+  testStructure "github.com/gruntwork-io/terratest/modules/test-structure"
+  "github.com/stretchr/testify/assert"
+  "github.com/stretchr/testify/require"
+)
+
+// Cassettes of the example tests, named after the tests
+const cassettesFolder = "testdata/cassettes"
+
+type vcrSession struct {
+  t *testing.T
+  // Random ID of the names of the test, seeded from the test name when replaying
+  randID string
+  // Environment of the Terraform commands
+  envVars map[string]string
+  proxy   *vcr.Proxy
+  // GitHub App of the environment, used instead of GITHUB_TOKEN when set
+  app *appauth.Config
+}
+
+// startVCR routes the calls of a test to the GitHub API, by the provider and by go-github, through a record/replay
+// proxy as set by GITHUB_VCR:
+//
+//   record  run against the live API, and record the interactions without tokens and secrets, and with the random ID
+//           replaced by one seeded from the test name
+//   replay  replay the recorded interactions offline, with the seeded ID; the providers are installed only from
+//           TF_PLUGIN_CACHE_DIR, filled by make test/providers, so terraform init -upgrade does not reach the registry
+//
+// Without GITHUB_VCR, tests run against the live API directly.
+//
+// Outside of replays, the provider and go-github authenticate as the GitHub App set by the GITHUB_APP_ID,
+// GITHUB_APP_INSTALLATION_ID and GITHUB_APP_PEM_FILE environment variables of the provider, if any, instead of
+// GITHUB_TOKEN.
+func startVCR(t *testing.T) *vcrSession {
+  mode, err := vcr.ParseMode(os.Getenv("GITHUB_VCR"))
+  require.NoError(t, err)
+  app, err := appauth.FromEnv()
+  require.NoError(t, err)
+
+  s := &vcrSession{t: t, randID: strings.ToLower(random.UniqueId()), app: app}
+  if mode == vcr.ModeLive {
+    s.providerVars()
+    return s
+  }
+
+  seed := vcr.Seed(t.Name())
+  cassetteFile := filepath.Join(cassettesFolder, strings.ReplaceAll(t.Name(), "/", "_")+".json")
+  var cassette *vcr.Cassette
+  scrubber := vcr.NewScrubber()
+  if mode == vcr.ModeReplay {
+    cassette, err = vcr.Load(cassetteFile)
+    if os.IsNotExist(err) {
+      t.Skipf("%s is not recorded, run the test with GITHUB_VCR=record", cassetteFile)
+    }
+    require.NoError(t, err)
+    s.randID = seed
+    s.app = nil
+  } else {
+    scrubber = vcr.NewScrubber(s.randID, seed)
+  }
+
+  s.proxy, err = vcr.NewProxy(mode, cassette, appauth.APIURL(baseURL), scrubber)
+  require.NoError(t, err)
+  // Cleanups run after the deferred terraform destroy of the test
+  t.Cleanup(func() {
+    assert.NoError(t, s.proxy.Close())
+    // Recordings of failed tests would fail the replays
+    if mode == vcr.ModeRecord && !t.Failed() {
+      assert.NoError(t, s.proxy.Cassette().Save(cassetteFile))
+    }
+  })
+
+  // The settings the provider does not manage yet are applied by scripts/github-api.sh of the module, configured by
+  // the environment
+  s.envVars = map[string]string{"GITHUB_BASE_URL": s.proxy.URL()}
+  if mode == vcr.ModeReplay {
+    // The provider requires a token, which the script uses instead of the app of the environment
+    s.envVars["GITHUB_TOKEN"] = vcr.Redacted
+    for _, name := range []string{appauth.EnvAppID, appauth.EnvInstallationID, appauth.EnvPEMFile} {
+      s.envVars[name] = ""
+    }
+    s.offlineProviders()
+  }
+  s.providerVars()
+  return s
+}
+
+// providerVars configures the provider of the examples, by their variables, with the owner and the GitHub instance of
+// the tests, and with the GitHub App of the session if any.
+func (s *vcrSession) providerVars() {
+  if s.envVars == nil {
+    s.envVars = map[string]string{}
+  }
+  s.envVars["TF_VAR_owner"] = owner
+  if url, _ := s.urls(); url != "" {
+    s.envVars["TF_VAR_github_base_url"] = url
+  }
+  if s.app != nil {
+    s.envVars["TF_VAR_github_app_auth"] = "true"
+  }
+}
+
+// offlineProviders configures Terraform to install the providers from TF_PLUGIN_CACHE_DIR only, and to skip its
+// version check, so replays run without network access.
+func (s *vcrSession) offlineProviders() {
+  cache := os.Getenv("TF_PLUGIN_CACHE_DIR")
+  require.NotEmpty(s.t, cache, "replays install the providers from TF_PLUGIN_CACHE_DIR, fill it with make test/providers")
+  cache, err := filepath.Abs(cache)
+  require.NoError(s.t, err)
+
+  config := filepath.Join(s.t.TempDir(), "terraformrc")
+  src := fmt.Sprintf("provider_installation {\n  filesystem_mirror {\n    path = %q\n  }\n}\n", cache)
+  require.NoError(s.t, os.WriteFile(config, []byte(src), 0o644))
+  s.envVars["TF_CLI_CONFIG_FILE"] = config
+  // The cache is the mirror of the replays
+  s.envVars["TF_PLUGIN_CACHE_DIR"] = ""
+  s.envVars["CHECKPOINT_DISABLE"] = "1"
+}
+
+// copyTerraformFolderToTemp copies the example at folder to a temporary folder.
+func (s *vcrSession) copyTerraformFolderToTemp(rootFolder, folder string) string {
+  return testStructure.CopyTerraformFolderToTemp(s.t, rootFolder, folder)
+}
+
+// client returns a go-github client of the GitHub instance, authenticated as the GitHub App of the session or with
+// GITHUB_TOKEN.
+func (s *vcrSession) client() *github.Client {
+  baseURL, uploadURL := s.urls()
+  client, err := appauth.NewClient(s.app, os.Getenv("GITHUB_TOKEN"), baseURL, uploadURL)
+  require.NoError(s.t, err)
+  return client
+}
+
+// urls returns the base and upload URLs of the GitHub instance, the proxy if any, empty for github.com.
+func (s *vcrSession) urls() (string, string) {
+  if s.proxy != nil {
+    return s.proxy.URL(), s.proxy.URL()
+  }
+  return baseURL, uploadURL
+}
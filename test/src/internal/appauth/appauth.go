@@ -0,0 +1,275 @@
+// Package appauth authenticates to the GitHub API as a GitHub App installation, as an alternative to personal
+// access tokens.
+//
+// The app is configured with the environment variables of the app_auth block of the Terraform provider, so that
+// the tests, the tools and the provider share one configuration. Installation tokens are requested with a JWT signed
+// by the private key of the app, and refreshed before they expire. Clients target github.com, or the GitHub Enterprise
+// Server set by GITHUB_BASE_URL as for the provider.
+package appauth
+
+import (
+  "context"
+  "crypto"
+  "crypto/rand"
+  "crypto/rsa"
+  "crypto/sha256"
+  "crypto/x509"
+  "encoding/base64"
+  "encoding/json"
+  "encoding/pem"
+  "errors"
+  "fmt"
+  "io"
+  "net/http"
+  "os"
+  "strings"
+  "sync"
+  "time"
+
+  "github.com/google/go-github/v73/github"
+)
+
+// Environment variables of the app_auth block of the Terraform provider
+const (
+  EnvAppID          = "GITHUB_APP_ID"
+  EnvInstallationID = "GITHUB_APP_INSTALLATION_ID"
+  EnvPEMFile        = "GITHUB_APP_PEM_FILE"
+)
+
+// Environment variables of the GitHub instance, the base URL being shared with the Terraform provider
+const (
+  EnvBaseURL   = "GITHUB_BASE_URL"
+  EnvUploadURL = "GITHUB_UPLOAD_URL"
+)
+
+const (
+  // API URL of github.com
+  defaultAPIURL = "https://api.github.com/"
+  // Lifetime of the JWT, only used to request installation tokens
+  jwtLifetime = 5 * time.Minute
+  // Allowed clock drift between the client and GitHub
+  clockDrift = time.Minute
+  // Installation tokens are refreshed when they expire within this margin
+  refreshMargin = 5 * time.Minute
+)
+
+// Config is a GitHub App installation.
+type Config struct {
+  // ID of the GitHub App
+  AppID string
+  // ID of the installation of the app in the owner of the repositories
+  InstallationID string
+  // PEM encoded PKCS #1 private key of the app
+  PrivateKey []byte
+}
+
+// FromEnv returns the app configured in the environment variables of the Terraform provider, or nil when none is.
+// As with the provider, escaped newlines of GITHUB_APP_PEM_FILE are replaced with newlines.
+func FromEnv() (*Config, error) {
+  c := &Config{
+    AppID:          os.Getenv(EnvAppID),
+    InstallationID: os.Getenv(EnvInstallationID),
+    PrivateKey:     []byte(strings.ReplaceAll(os.Getenv(EnvPEMFile), `\n`, "\n")),
+  }
+  if c.AppID == "" && c.InstallationID == "" && len(c.PrivateKey) == 0 {
+    return nil, nil
+  }
+  if c.AppID == "" || c.InstallationID == "" || len(c.PrivateKey) == 0 {
+    return nil, fmt.Errorf("%s, %s and %s must all be set", EnvAppID, EnvInstallationID, EnvPEMFile)
+  }
+  return c, nil
+}
+
+// JWT returns a JSON Web Token authenticating as the app, issued at now.
+func (c Config) JWT(now time.Time) (string, error) {
+  key, err := c.key()
+  if err != nil {
+    return "", err
+  }
+  return signJWT(key, c.AppID, now)
+}
+
+func (c Config) key() (*rsa.PrivateKey, error) {
+  block, _ := pem.Decode(c.PrivateKey)
+  if block == nil {
+    return nil, errors.New("no PEM data found in the private key of the app")
+  }
+  key, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+  if err != nil {
+    return nil, fmt.Errorf("parsing the private key of the app: %w", err)
+  }
+  return key, nil
+}
+
+func signJWT(key *rsa.PrivateKey, appID string, now time.Time) (string, error) {
+  header, err := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT"})
+  if err != nil {
+    return "", err
+  }
+  claims, err := json.Marshal(map[string]interface{}{
+    "iss": appID,
+    "iat": now.Add(-clockDrift).Unix(),
+    "exp": now.Add(jwtLifetime).Unix(),
+  })
+  if err != nil {
+    return "", err
+  }
+
+  unsigned := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(claims)
+  digest := sha256.Sum256([]byte(unsigned))
+  signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+  if err != nil {
+    return "", err
+  }
+  return unsigned + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+// Transport authenticates requests with an installation token of the app, requested from the API at apiURL.
+type Transport struct {
+  // Transport of the requests and of the token requests
+  Base   http.RoundTripper
+  config Config
+  key    *rsa.PrivateKey
+  apiURL string
+
+  mu      sync.Mutex
+  token   string
+  expires time.Time
+}
+
+// NewTransport returns a transport authenticating as the installation of config. apiURL is the REST API URL, such
+// as https://api.github.com/ or https://github.example.com/api/v3/.
+func NewTransport(config Config, apiURL string, base http.RoundTripper) (*Transport, error) {
+  key, err := config.key()
+  if err != nil {
+    return nil, err
+  }
+  if !strings.HasSuffix(apiURL, "/") {
+    apiURL += "/"
+  }
+  return &Transport{Base: base, config: config, key: key, apiURL: apiURL}, nil
+}
+
+// RoundTrip sends req with the Authorization header of the installation token.
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+  token, err := t.Token(req.Context())
+  if err != nil {
+    return nil, err
+  }
+  req = req.Clone(req.Context())
+  req.Header.Set("Authorization", "Bearer "+token)
+  return t.base().RoundTrip(req)
+}
+
+// Token returns the installation token, requesting a new one when it expires within the refresh margin.
+func (t *Transport) Token(ctx context.Context) (string, error) {
+  t.mu.Lock()
+  defer t.mu.Unlock()
+
+  if t.token != "" && now().Add(refreshMargin).Before(t.expires) {
+    return t.token, nil
+  }
+
+  jwt, err := signJWT(t.key, t.config.AppID, now())
+  if err != nil {
+    return "", err
+  }
+  url := fmt.Sprintf("%sapp/installations/%s/access_tokens", t.apiURL, t.config.InstallationID)
+  tokenReq, err := http.NewRequestWithContext(ctx, http.MethodPost, url, nil)
+  if err != nil {
+    return "", err
+  }
+  tokenReq.Header.Set("Accept", "application/vnd.github+json")
+  tokenReq.Header.Set("Authorization", "Bearer "+jwt)
+
+  resp, err := t.base().RoundTrip(tokenReq)
+  if err != nil {
+    return "", fmt.Errorf("requesting an installation token: %w", err)
+  }
+  defer resp.Body.Close()
+  body, err := io.ReadAll(resp.Body)
+  if err != nil {
+    return "", fmt.Errorf("requesting an installation token: %w", err)
+  }
+  if resp.StatusCode != http.StatusCreated {
+    return "", fmt.Errorf("requesting an installation token: %s: %s", resp.Status, body)
+  }
+
+  var token struct {
+    Token     string    `json:"token"`
+    ExpiresAt time.Time `json:"expires_at"`
+  }
+  if err := json.Unmarshal(body, &token); err != nil {
+    return "", fmt.Errorf("decoding the installation token: %w", err)
+  }
+  t.token, t.expires = token.Token, token.ExpiresAt
+  return t.token, nil
+}
+
+func (t *Transport) base() http.RoundTripper {
+  if t.Base != nil {
+    return t.Base
+  }
+  return http.DefaultTransport
+}
+
+// NewClient returns a go-github client authenticated as the installation of config when not nil, or with token
+// otherwise. baseURL and uploadURL are the URLs of a GitHub Enterprise Server, empty for github.com. An empty
+// uploadURL is baseURL.
+func NewClient(config *Config, token, baseURL, uploadURL string) (*github.Client, error) {
+  var client *github.Client
+  if config == nil {
+    client = github.NewClient(nil).WithAuthToken(token)
+  } else {
+    transport, err := NewTransport(*config, APIURL(baseURL), nil)
+    if err != nil {
+      return nil, err
+    }
+    client = github.NewClient(&http.Client{Transport: transport})
+  }
+  if baseURL == "" {
+    return client, nil
+  }
+  if uploadURL == "" {
+    uploadURL = baseURL
+  }
+  return client.WithEnterpriseURLs(baseURL, uploadURL)
+}
+
+// ClientFromEnv returns a go-github client of the GitHub instance of the environment, authenticated as the app
+// configured in the environment, or with the GITHUB_TOKEN environment variable otherwise.
+func ClientFromEnv() (*github.Client, error) {
+  config, err := FromEnv()
+  if err != nil {
+    return nil, err
+  }
+  return NewClient(config, os.Getenv("GITHUB_TOKEN"), BaseURLFromEnv(), os.Getenv(EnvUploadURL))
+}
+
+// BaseURLFromEnv returns the URL of the GitHub Enterprise Server set by GITHUB_BASE_URL, or an empty string for
+// github.com. As with the provider, the base URL may also be the API URL of github.com.
+func BaseURLFromEnv() string {
+  baseURL := os.Getenv(EnvBaseURL)
+  if strings.HasPrefix(baseURL, strings.TrimSuffix(defaultAPIURL, "/")) {
+    return ""
+  }
+  return baseURL
+}
+
+// APIURL returns the REST API URL of baseURL, as set by go-github for GitHub Enterprise Server, or the API URL of
+// github.com for an empty baseURL.
+func APIURL(baseURL string) string {
+  if baseURL == "" {
+    return defaultAPIURL
+  }
+  if !strings.HasSuffix(baseURL, "/") {
+    baseURL += "/"
+  }
+  if !strings.HasSuffix(baseURL, "/api/v3/") {
+    baseURL += "api/v3/"
+  }
+  return baseURL
+}
+
+// The clock, replaced by tests
+var now = time.Now
@@ -0,0 +1,191 @@
+package appauth
+
+import (
+  "context"
+  "crypto"
+  "crypto/rand"
+  "crypto/rsa"
+  "crypto/sha256"
+  "crypto/x509"
+  "encoding/base64"
+  "encoding/json"
+  "encoding/pem"
+  "fmt"
+  "net/http"
+  "net/http/httptest"
+  "strings"
+  "sync"
+  "testing"
+  "time"
+
+  "github.com/stretchr/testify/assert"
+  "github.com/stretchr/testify/require"
+)
+
+// newKey returns a private key generated for the test, and its PEM encoding.
+func newKey(t *testing.T) (*rsa.PrivateKey, []byte) {
+  key, err := rsa.GenerateKey(rand.Reader, 2048)
+  require.NoError(t, err)
+  return key, pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+}
+
+// verifyJWT checks the signature of jwt with key, returning its claims.
+func verifyJWT(t *testing.T, key *rsa.PublicKey, jwt string) map[string]interface{} {
+  parts := strings.Split(jwt, ".")
+  require.Len(t, parts, 3)
+
+  signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+  require.NoError(t, err)
+  digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+  require.NoError(t, rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], signature))
+
+  var header, claims map[string]interface{}
+  for i, v := range []*map[string]interface{}{&header, &claims} {
+    data, err := base64.RawURLEncoding.DecodeString(parts[i])
+    require.NoError(t, err)
+    require.NoError(t, json.Unmarshal(data, v))
+  }
+  assert.Equal(t, map[string]interface{}{"alg": "RS256", "typ": "JWT"}, header)
+  return claims
+}
+
+// fakeGitHub is an API issuing installation tokens valid for an hour, and echoing the Authorization header of
+// other requests.
+type fakeGitHub struct {
+  *httptest.Server
+  mu     sync.Mutex
+  tokens int
+}
+
+func newFakeGitHub(t *testing.T, key *rsa.PublicKey) *fakeGitHub {
+  f := &fakeGitHub{}
+  mux := http.NewServeMux()
+  mux.HandleFunc("POST /api/v3/app/installations/{id}/access_tokens", func(w http.ResponseWriter, r *http.Request) {
+    claims := verifyJWT(t, key, strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "))
+    if r.PathValue("id") != "42" || claims["iss"] != "1234" {
+      w.WriteHeader(http.StatusNotFound)
+      return
+    }
+    f.mu.Lock()
+    f.tokens++
+    token := fmt.Sprintf("installation-token-%d", f.tokens)
+    f.mu.Unlock()
+
+    w.WriteHeader(http.StatusCreated)
+    json.NewEncoder(w).Encode(map[string]interface{}{"token": token, "expires_at": now().Add(time.Hour)})
+  })
+  mux.HandleFunc("GET /api/v3/user", func(w http.ResponseWriter, r *http.Request) {
+    json.NewEncoder(w).Encode(map[string]string{"login": r.Header.Get("Authorization")})
+  })
+  f.Server = httptest.NewServer(mux)
+  t.Cleanup(f.Close)
+  return f
+}
+
+// fakeClock replaces the clock of the package, returning a function advancing it.
+func fakeClock(t *testing.T) func(d time.Duration) {
+  clock := time.Now().UTC().Truncate(time.Second)
+  original := now
+  now = func() time.Time { return clock }
+  t.Cleanup(func() { now = original })
+  return func(d time.Duration) { clock = clock.Add(d) }
+}
+
+// Test that the JWT is signed by the key of the app, with claims accepted by GitHub.
+func TestJWT(t *testing.T) {
+  key, keyPEM := newKey(t)
+  issued := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+  jwt, err := Config{AppID: "1234", PrivateKey: keyPEM}.JWT(issued)
+  require.NoError(t, err)
+  claims := verifyJWT(t, &key.PublicKey, jwt)
+  assert.Equal(t, "1234", claims["iss"])
+  assert.EqualValues(t, issued.Add(-time.Minute).Unix(), claims["iat"])
+  assert.EqualValues(t, issued.Add(5*time.Minute).Unix(), claims["exp"])
+
+  _, err = Config{AppID: "1234", PrivateKey: []byte("not a key")}.JWT(issued)
+  assert.ErrorContains(t, err, "no PEM data")
+}
+
+// Test that requests are authenticated with an installation token, refreshed before it expires.
+func TestClient(t *testing.T) {
+  advance := fakeClock(t)
+  key, keyPEM := newKey(t)
+  server := newFakeGitHub(t, &key.PublicKey)
+
+  client, err := NewClient(&Config{AppID: "1234", InstallationID: "42", PrivateKey: keyPEM}, "", server.URL, "")
+  require.NoError(t, err)
+
+  for _, want := range []string{"installation-token-1", "installation-token-1"} {
+    user, _, err := client.Users.Get(context.Background(), "")
+    require.NoError(t, err)
+    assert.Equal(t, "Bearer "+want, user.GetLogin())
+  }
+
+  advance(50 * time.Minute)
+  user, _, err := client.Users.Get(context.Background(), "")
+  require.NoError(t, err)
+  assert.Equal(t, "Bearer installation-token-1", user.GetLogin())
+
+  advance(6 * time.Minute)
+  user, _, err = client.Users.Get(context.Background(), "")
+  require.NoError(t, err)
+  assert.Equal(t, "Bearer installation-token-2", user.GetLogin())
+  assert.Equal(t, 2, server.tokens)
+
+  client, err = NewClient(&Config{AppID: "1234", InstallationID: "43", PrivateKey: keyPEM}, "", server.URL, "")
+  require.NoError(t, err)
+  _, _, err = client.Users.Get(context.Background(), "")
+  assert.ErrorContains(t, err, "requesting an installation token: 404 Not Found")
+}
+
+// Test that personal access tokens are used without an app.
+func TestClientToken(t *testing.T) {
+  key, _ := newKey(t)
+  server := newFakeGitHub(t, &key.PublicKey)
+
+  client, err := NewClient(nil, "personal-token", server.URL, "")
+  require.NoError(t, err)
+  user, _, err := client.Users.Get(context.Background(), "")
+  require.NoError(t, err)
+  assert.Equal(t, "Bearer personal-token", user.GetLogin())
+  assert.Equal(t, 0, server.tokens)
+}
+
+// Test that the app is read from the environment variables of the provider.
+func TestFromEnv(t *testing.T) {
+  _, keyPEM := newKey(t)
+  t.Setenv(EnvAppID, "")
+  t.Setenv(EnvInstallationID, "")
+  t.Setenv(EnvPEMFile, "")
+
+  config, err := FromEnv()
+  assert.NoError(t, err)
+  assert.Nil(t, config)
+
+  t.Setenv(EnvAppID, "1234")
+  _, err = FromEnv()
+  assert.ErrorContains(t, err, "must all be set")
+
+  t.Setenv(EnvInstallationID, "42")
+  t.Setenv(EnvPEMFile, strings.ReplaceAll(string(keyPEM), "\n", `\n`))
+  config, err = FromEnv()
+  require.NoError(t, err)
+  assert.Equal(t, &Config{AppID: "1234", InstallationID: "42", PrivateKey: keyPEM}, config)
+}
+
+// Test that Enterprise Server URLs are completed as by go-github, and that github.com is the default.
+func TestAPIURL(t *testing.T) {
+  assert.Equal(t, "https://api.github.com/", APIURL(""))
+  assert.Equal(t, "https://github.example.com/api/v3/", APIURL("https://github.example.com"))
+  assert.Equal(t, "https://github.example.com/api/v3/", APIURL("https://github.example.com/api/v3/"))
+
+  for value, want := range map[string]string{
+    "":                            "",
+    "https://api.github.com/":     "",
+    "https://github.example.com/": "https://github.example.com/",
+  } {
+    t.Setenv(EnvBaseURL, value)
+    assert.Equal(t, want, BaseURLFromEnv())
+  }
+}
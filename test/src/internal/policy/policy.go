@@ -0,0 +1,244 @@
+// Package policy evaluates rules written in CEL (https://github.com/google/cel-spec) on the resources of a plan, as
+// output by terraform show -json.
+//
+// A rule applies to the managed resources of the listed types, or of any type when none is listed, which are
+// created, updated or kept by the plan, and for which the optional condition is true. It passes for a resource when its assertion is true. Both expressions
+// see the variables:
+//
+//   resource   the resource, with keys address, module_address, type, name, actions and values
+//   resources  every resource of the plan, in the same format
+//
+// The values of a resource are its planned attributes, where nested blocks are lists. Attributes only known after
+// apply are absent, so rules on them should test has(resource.values.name) first.
+package policy
+
+import (
+  _ "embed"
+  "encoding/json"
+  "fmt"
+  "sort"
+
+  "github.com/google/cel-go/cel"
+  "gopkg.in/yaml.v3"
+)
+
+//go:embed builtin.yaml
+var builtin []byte
+
+// Rule is a policy, as written in a rules file.
+type Rule struct {
+  Name        string   `yaml:"name" json:"name"`
+  Description string   `yaml:"description" json:"description"`
+  Types       []string `yaml:"types" json:"types"`
+  Condition   string   `yaml:"condition" json:"condition"`
+  Assert      string   `yaml:"assert" json:"assert"`
+}
+
+// Result is the outcome of a rule for a resource.
+type Result struct {
+  Rule        string `json:"rule"`
+  Description string `json:"description"`
+  Address     string `json:"address"`
+  Passed      bool   `json:"passed"`
+  Error       string `json:"error,omitempty"`
+}
+
+// Plan is the part of a terraform show -json plan the rules are evaluated on.
+type Plan struct {
+  FormatVersion   string           `json:"format_version"`
+  ResourceChanges []ResourceChange `json:"resource_changes"`
+}
+
+// ResourceChange is a resource of the plan, with its planned values.
+type ResourceChange struct {
+  Address       string `json:"address"`
+  ModuleAddress string `json:"module_address"`
+  Mode          string `json:"mode"`
+  Type          string `json:"type"`
+  Name          string `json:"name"`
+  Change        struct {
+    Actions []string    `json:"actions"`
+    After   interface{} `json:"after"`
+  } `json:"change"`
+}
+
+// ParsePlan parses the output of terraform show -json for a plan file.
+func ParsePlan(src []byte) (*Plan, error) {
+  var plan Plan
+  if err := json.Unmarshal(src, &plan); err != nil {
+    return nil, err
+  }
+  if plan.FormatVersion == "" {
+    return nil, fmt.Errorf("not the output of terraform show -json")
+  }
+  return &plan, nil
+}
+
+// Builtin returns the rules of the organization, evaluated unless disabled.
+func Builtin() []Rule {
+  rules, err := ParseRules(builtin)
+  if err != nil {
+    panic(err)
+  }
+  return rules
+}
+
+// ParseRules parses a list of rules in YAML or JSON.
+func ParseRules(src []byte) ([]Rule, error) {
+  var rules []Rule
+  if err := yaml.Unmarshal(src, &rules); err != nil {
+    return nil, err
+  }
+  for i, rule := range rules {
+    if rule.Name == "" {
+      return nil, fmt.Errorf("rule %d: name is required", i+1)
+    }
+    if rule.Assert == "" {
+      return nil, fmt.Errorf("rule %s: assert is required", rule.Name)
+    }
+  }
+  return rules, nil
+}
+
+// Engine evaluates compiled rules.
+type Engine struct {
+  rules []compiledRule
+}
+
+type compiledRule struct {
+  Rule
+  types     map[string]bool
+  condition cel.Program
+  assert    cel.Program
+}
+
+// NewEngine compiles rules, failing on the first invalid expression.
+func NewEngine(rules []Rule) (*Engine, error) {
+  env, err := cel.NewEnv(
+    cel.Variable("resource", cel.MapType(cel.StringType, cel.DynType)),
+    cel.Variable("resources", cel.ListType(cel.MapType(cel.StringType, cel.DynType))),
+    // Numbers of the plan are doubles
+    cel.CrossTypeNumericComparisons(true),
+  )
+  if err != nil {
+    return nil, err
+  }
+
+  e := &Engine{}
+  names := make(map[string]bool)
+  for _, rule := range rules {
+    if names[rule.Name] {
+      return nil, fmt.Errorf("rule %s: duplicate name", rule.Name)
+    }
+    names[rule.Name] = true
+
+    c := compiledRule{Rule: rule, types: make(map[string]bool)}
+    for _, t := range rule.Types {
+      c.types[t] = true
+    }
+    if rule.Condition != "" {
+      if c.condition, err = compile(env, rule.Condition); err != nil {
+        return nil, fmt.Errorf("rule %s: condition: %w", rule.Name, err)
+      }
+    }
+    if c.assert, err = compile(env, rule.Assert); err != nil {
+      return nil, fmt.Errorf("rule %s: assert: %w", rule.Name, err)
+    }
+    e.rules = append(e.rules, c)
+  }
+  return e, nil
+}
+
+func compile(env *cel.Env, expr string) (cel.Program, error) {
+  ast, issues := env.Compile(expr)
+  if issues.Err() != nil {
+    return nil, issues.Err()
+  }
+  if t := ast.OutputType(); t != cel.BoolType && t != cel.DynType {
+    return nil, fmt.Errorf("must be a bool, not %s", t)
+  }
+  return env.Program(ast)
+}
+
+// Evaluate returns the results of the rules for the resources they apply to, by rule and address.
+func (e *Engine) Evaluate(plan *Plan) []Result {
+  var resources []map[string]interface{}
+  for _, rc := range plan.ResourceChanges {
+    // Resources destroyed by the plan have no values to check
+    if rc.Mode != "managed" || rc.Change.After == nil {
+      continue
+    }
+    actions := make([]interface{}, len(rc.Change.Actions))
+    for i, action := range rc.Change.Actions {
+      actions[i] = action
+    }
+    resources = append(resources, map[string]interface{}{
+      "address":        rc.Address,
+      "module_address": rc.ModuleAddress,
+      "type":           rc.Type,
+      "name":           rc.Name,
+      "actions":        actions,
+      "values":         rc.Change.After,
+    })
+  }
+  sort.Slice(resources, func(i, j int) bool {
+    return resources[i]["address"].(string) < resources[j]["address"].(string)
+  })
+  all := make([]interface{}, len(resources))
+  for i, resource := range resources {
+    all[i] = resource
+  }
+
+  var results []Result
+  for _, rule := range e.rules {
+    for _, resource := range resources {
+      if len(rule.types) > 0 && !rule.types[resource["type"].(string)] {
+        continue
+      }
+      vars := map[string]interface{}{"resource": resource, "resources": all}
+
+      result := Result{Rule: rule.Name, Description: rule.Description, Address: resource["address"].(string)}
+      if rule.condition != nil {
+        applies, err := eval(rule.condition, vars)
+        if err != nil {
+          result.Error = fmt.Sprintf("condition: %s", err)
+          results = append(results, result)
+          continue
+        }
+        if !applies {
+          continue
+        }
+      }
+
+      passed, err := eval(rule.assert, vars)
+      if err != nil {
+        result.Error = err.Error()
+      }
+      result.Passed = passed
+      results = append(results, result)
+    }
+  }
+  return results
+}
+
+func eval(program cel.Program, vars map[string]interface{}) (bool, error) {
+  out, _, err := program.Eval(vars)
+  if err != nil {
+    return false, err
+  }
+  b, ok := out.Value().(bool)
+  if !ok {
+    return false, fmt.Errorf("result is a %s, not a bool", out.Type())
+  }
+  return b, nil
+}
+
+// Failed reports whether any result failed.
+func Failed(results []Result) bool {
+  for _, result := range results {
+    if !result.Passed {
+      return true
+    }
+  }
+  return false
+}
@@ -0,0 +1,120 @@
+package policy
+
+import (
+  "os"
+  "testing"
+
+  "github.com/stretchr/testify/assert"
+  "github.com/stretchr/testify/require"
+)
+
+func loadPlan(t *testing.T) *Plan {
+  src, err := os.ReadFile("testdata/plan.json")
+  require.NoError(t, err)
+  plan, err := ParsePlan(src)
+  require.NoError(t, err)
+  return plan
+}
+
+// Test the builtin rules on a plan with compliant and non compliant resources.
+func TestBuiltin(t *testing.T) {
+  engine, err := NewEngine(Builtin())
+  require.NoError(t, err)
+
+  results := engine.Evaluate(loadPlan(t))
+  outcomes := make(map[string]bool)
+  for _, result := range results {
+    assert.Empty(t, result.Error, "%s %s", result.Rule, result.Address)
+    outcomes[result.Rule+" "+result.Address] = result.Passed
+  }
+
+  assert.Equal(t, map[string]bool{
+    `public-repository-push-protection module.example.github_repository.default[0]`:                          true,
+    `public-repository-push-protection module.other.github_repository.default[0]`:                            false,
+    `default-branch-approval module.example.github_repository_ruleset.default["default"]`:                    true,
+    `default-branch-approval module.example.github_repository_ruleset.default["main"]`:                       false,
+    `default-branch-approval module.organization.github_organization_ruleset.default["default"]`:             true,
+    `webhook-secure-ssl module.example.github_repository_webhook.default["ci"]`:                              true,
+    `webhook-secure-ssl module.example.github_repository_webhook.default["legacy"]`:                          false,
+    `production-environment-admin-bypass module.example.github_repository_environment.default["production"]`: false,
+  }, outcomes)
+  assert.True(t, Failed(results))
+}
+
+// Test user supplied rules alongside the builtin rules.
+func TestUserRules(t *testing.T) {
+  rules, err := ParseRules([]byte(`
+- name: no-wiki
+  description: Wikis must be disabled
+  types: [github_repository]
+  assert: has(resource.values.has_wiki) && !resource.values.has_wiki
+
+- name: labels-kept
+  description: Labels must not be destroyed
+  assert: '!resources.exists(r, r.type == "github_issue_label")'
+
+- name: described
+  types: [github_repository]
+  condition: resource.values.visibility == "public"
+  assert: resource.values.description != ""
+`))
+  require.NoError(t, err)
+
+  engine, err := NewEngine(append(Builtin(), rules...))
+  require.NoError(t, err)
+
+  outcomes := make(map[string]bool)
+  labelsKept := 0
+  for _, result := range engine.Evaluate(loadPlan(t)) {
+    switch result.Rule {
+    case "no-wiki":
+      outcomes[result.Address] = result.Passed
+    case "labels-kept":
+      // Applies to every resource, and the destroyed label is not a resource of the plan
+      assert.True(t, result.Passed, result.Address)
+      labelsKept++
+    case "described":
+      // Attributes missing from the plan are errors
+      assert.False(t, result.Passed)
+      assert.Contains(t, result.Error, "no such key: description")
+    }
+  }
+
+  assert.Equal(t, map[string]bool{
+    "module.example.github_repository.default[0]": true,
+    "module.other.github_repository.default[0]":   false,
+    "module.other.github_repository.private[0]":   false,
+  }, outcomes)
+  assert.Equal(t, 14, labelsKept)
+}
+
+// Test that invalid rules are refused.
+func TestInvalidRules(t *testing.T) {
+  for _, tc := range []struct {
+    name  string
+    rules string
+    err   string
+  }{
+    {"name", `[{"assert": "true"}]`, "rule 1: name is required"},
+    {"assert", `[{"name": "empty"}]`, "rule empty: assert is required"},
+    {"syntax", `[{"name": "syntax", "assert": "resource.values."}]`, "rule syntax: assert: "},
+    {"type", `[{"name": "type", "condition": "resource.type + 1", "assert": "true"}]`, "rule type: condition: "},
+    {"bool", `[{"name": "bool", "assert": "'yes'"}]`, "rule bool: assert: must be a bool, not string"},
+    {"duplicate", `[{"name": "twice", "assert": "true"}, {"name": "twice", "assert": "false"}]`, "rule twice: duplicate name"},
+  } {
+    t.Run(tc.name, func(t *testing.T) {
+      rules, err := ParseRules([]byte(tc.rules))
+      if err == nil {
+        _, err = NewEngine(rules)
+      }
+      require.Error(t, err)
+      assert.Contains(t, err.Error(), tc.err)
+    })
+  }
+}
+
+// Test that other JSON documents are not mistaken for plans.
+func TestParsePlan(t *testing.T) {
+  _, err := ParsePlan([]byte(`{"resource_changes": []}`))
+  assert.EqualError(t, err, "not the output of terraform show -json")
+}
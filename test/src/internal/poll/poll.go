@@ -0,0 +1,123 @@
+// Package poll retries reads of the GitHub API until their result is ready, as changes made by Terraform are not
+// always visible to the reads which follow.
+//
+// Reads are retried with an exponential backoff until a predicate holds or the timeout passes. Rate limited reads
+// wait as told by GitHub: until the reset of the primary rate limit, for the Retry-After delay of secondary rate
+// limits, or at least a minute when a secondary rate limit gives no delay.
+package poll
+
+import (
+  "context"
+  "errors"
+  "net/http"
+  "strconv"
+  "time"
+
+  "github.com/google/go-github/v73/github"
+)
+
+// Wait after a secondary rate limit without Retry-After, as documented by GitHub
+const secondaryRateLimitWait = time.Minute
+
+// Backoff is how long reads are retried.
+type Backoff struct {
+  // Delay before the first retry, doubled for each retry
+  Initial time.Duration
+  // Maximum delay between retries
+  Max time.Duration
+  // Time after which the last result is returned, ready or not
+  Timeout time.Duration
+}
+
+// DefaultBackoff suits reads following a terraform apply.
+var DefaultBackoff = Backoff{Initial: time.Second, Max: 15 * time.Second, Timeout: 2 * time.Minute}
+
+// Read is a read of the API, usually a go-github method bound to its arguments.
+type Read[T any] func(ctx context.Context) (T, *github.Response, error)
+
+// Ready reports whether the result of a read is final. Rate limits and server errors are retried without calling it.
+type Ready[T any] func(v T, err error) bool
+
+// Succeeded is ready when the read succeeds, retrying reads of resources not visible yet.
+func Succeeded[T any](_ T, err error) bool {
+  return err == nil
+}
+
+// Failed is ready when the read fails, for resources which are expected to be absent.
+func Failed[T any](_ T, err error) bool {
+  return err != nil
+}
+
+// Until calls read until ready holds for its result, returning the last result when the timeout passes or ctx is
+// done. A nil ready is Succeeded.
+func Until[T any](ctx context.Context, b Backoff, read Read[T], ready Ready[T]) (T, *github.Response, error) {
+  if ready == nil {
+    ready = Succeeded[T]
+  }
+  deadline := now().Add(b.Timeout)
+  backoff := b.Initial
+
+  for {
+    v, resp, err := read(ctx)
+
+    delay, retry := retryDelay(resp, err)
+    if !retry && ready(v, err) {
+      return v, resp, err
+    }
+    if delay == 0 {
+      delay = backoff
+      backoff = min(2*backoff, b.Max)
+    }
+
+    if now().Add(delay).After(deadline) {
+      return v, resp, err
+    }
+    if sleep(ctx, delay) != nil {
+      return v, resp, err
+    }
+  }
+}
+
+// retryDelay returns the delay GitHub asks to wait, if any, and whether a read is retried without calling the
+// predicate, as it is rate limited or failed on the server.
+func retryDelay(resp *github.Response, err error) (time.Duration, bool) {
+  var rateLimit *github.RateLimitError
+  if errors.As(err, &rateLimit) {
+    return max(rateLimit.Rate.Reset.Time.Sub(now()), time.Second), true
+  }
+
+  var abuseRateLimit *github.AbuseRateLimitError
+  if errors.As(err, &abuseRateLimit) {
+    if abuseRateLimit.RetryAfter != nil {
+      return *abuseRateLimit.RetryAfter, true
+    }
+    return secondaryRateLimitWait, true
+  }
+
+  if resp == nil || err == nil {
+    return 0, false
+  }
+  switch resp.StatusCode {
+  case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+    if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+      return time.Duration(seconds) * time.Second, true
+    }
+    return 0, true
+  }
+  return 0, false
+}
+
+// The clock, replaced by tests
+var now = time.Now
+
+// sleep waits for d, or until ctx is done.
+var sleep = func(ctx context.Context, d time.Duration) error {
+  timer := time.NewTimer(d)
+  defer timer.Stop()
+  select {
+  case <-ctx.Done():
+    return ctx.Err()
+  case <-timer.C:
+    return nil
+  }
+}
@@ -0,0 +1,139 @@
+package poll
+
+import (
+  "context"
+  "net/http"
+  "testing"
+  "time"
+
+  "github.com/google/go-github/v73/github"
+  "github.com/stretchr/testify/assert"
+)
+
+var backoff = Backoff{Initial: time.Second, Max: 4 * time.Second, Timeout: 30 * time.Second}
+
+// fakeClock replaces the clock and sleep of the package, returning the delays slept.
+func fakeClock(t *testing.T) *[]time.Duration {
+  clock := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+  var delays []time.Duration
+
+  originalNow, originalSleep := now, sleep
+  now = func() time.Time { return clock }
+  sleep = func(ctx context.Context, d time.Duration) error {
+    delays = append(delays, d)
+    clock = clock.Add(d)
+    return ctx.Err()
+  }
+  t.Cleanup(func() { now, sleep = originalNow, originalSleep })
+  return &delays
+}
+
+func response(status int, header http.Header) *github.Response {
+  return &github.Response{Response: &http.Response{StatusCode: status, Header: header}}
+}
+
+// reads returns a read returning the results in turn, then the last one.
+func reads(results ...func() (int, *github.Response, error)) (Read[int], *int) {
+  calls := 0
+  return func(context.Context) (int, *github.Response, error) {
+    result := results[min(calls, len(results)-1)]
+    calls++
+    return result()
+  }, &calls
+}
+
+func ok(v int) func() (int, *github.Response, error) {
+  return func() (int, *github.Response, error) { return v, response(http.StatusOK, nil), nil }
+}
+
+func failed(status int, header http.Header) func() (int, *github.Response, error) {
+  return func() (int, *github.Response, error) {
+    resp := response(status, header)
+    return 0, resp, &github.ErrorResponse{Response: resp.Response, Message: http.StatusText(status)}
+  }
+}
+
+// Test that stale reads are retried with an exponential backoff until the predicate holds.
+func TestUntilReady(t *testing.T) {
+  delays := fakeClock(t)
+  read, calls := reads(ok(0), ok(0), ok(1), ok(2))
+
+  v, _, err := Until(context.Background(), backoff, read, func(v int, err error) bool { return err == nil && v > 0 })
+  assert.NoError(t, err)
+  assert.Equal(t, 1, v)
+  assert.Equal(t, 3, *calls)
+  assert.Equal(t, []time.Duration{time.Second, 2 * time.Second}, *delays)
+}
+
+// Test that the last result is returned when the timeout passes, with delays capped to the maximum.
+func TestUntilTimeout(t *testing.T) {
+  delays := fakeClock(t)
+  read, calls := reads(ok(0))
+
+  v, _, err := Until(context.Background(), Backoff{Initial: time.Second, Max: 4 * time.Second, Timeout: 14 * time.Second}, read, func(v int, err error) bool { return v > 0 })
+  assert.NoError(t, err)
+  assert.Equal(t, 0, v)
+  assert.Equal(t, 5, *calls)
+  assert.Equal(t, []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 4 * time.Second}, *delays)
+}
+
+// Test that resources not visible yet are retried by default, and that absent resources can be waited for.
+func TestUntilNotFound(t *testing.T) {
+  delays := fakeClock(t)
+  read, calls := reads(failed(http.StatusNotFound, nil), ok(1))
+
+  v, _, err := Until(context.Background(), backoff, read, nil)
+  assert.NoError(t, err)
+  assert.Equal(t, 1, v)
+  assert.Equal(t, 2, *calls)
+
+  read, calls = reads(ok(1), failed(http.StatusNotFound, nil))
+  _, resp, err := Until(context.Background(), backoff, read, Failed[int])
+  assert.Error(t, err)
+  assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+  assert.Equal(t, 2, *calls)
+  assert.Equal(t, []time.Duration{time.Second, time.Second}, *delays)
+}
+
+// Test that rate limits and server errors wait as told by GitHub, and are retried even when the predicate would hold.
+func TestUntilRateLimits(t *testing.T) {
+  delays := fakeClock(t)
+  retryAfter := 30 * time.Second
+
+  read, calls := reads(
+    func() (int, *github.Response, error) {
+      resp := response(http.StatusForbidden, nil)
+      return 0, resp, &github.AbuseRateLimitError{Response: resp.Response, RetryAfter: &retryAfter}
+    },
+    func() (int, *github.Response, error) {
+      resp := response(http.StatusForbidden, nil)
+      return 0, resp, &github.AbuseRateLimitError{Response: resp.Response}
+    },
+    func() (int, *github.Response, error) {
+      resp := response(http.StatusForbidden, nil)
+      reset := github.Timestamp{Time: now().Add(10 * time.Minute)}
+      return 0, resp, &github.RateLimitError{Response: resp.Response, Rate: github.Rate{Remaining: 0, Reset: reset}}
+    },
+    failed(http.StatusBadGateway, http.Header{"Retry-After": []string{"5"}}),
+    failed(http.StatusServiceUnavailable, nil),
+    failed(http.StatusNotFound, nil),
+  )
+
+  _, resp, err := Until(context.Background(), Backoff{Initial: time.Second, Max: time.Second, Timeout: time.Hour}, read, Failed[int])
+  assert.Error(t, err)
+  assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+  assert.Equal(t, 6, *calls)
+  assert.Equal(t, []time.Duration{retryAfter, time.Minute, 10 * time.Minute, 5 * time.Second, time.Second}, *delays)
+}
+
+// Test that reads stop when the context is done.
+func TestUntilCanceled(t *testing.T) {
+  fakeClock(t)
+  ctx, cancel := context.WithCancel(context.Background())
+  cancel()
+  read, calls := reads(ok(0))
+
+  _, _, err := Until(ctx, backoff, read, func(v int, err error) bool { return v > 0 })
+  assert.NoError(t, err)
+  assert.Equal(t, 1, *calls)
+}
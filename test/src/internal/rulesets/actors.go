@@ -0,0 +1,102 @@
+package rulesets
+
+import (
+  "context"
+  "strconv"
+
+  "github.com/google/go-github/v73/github"
+)
+
+// Built-in repository roles, as resolved by the module
+var builtinRoles = map[string]int64{
+  "maintain": 2,
+  "write":    4,
+  "admin":    5,
+}
+
+// Actors maps bypass actor and status check integration IDs to the names accepted by the module, and back.
+type Actors struct {
+  names map[string]map[int64]string
+  ids   map[string]map[string]int64
+}
+
+// NewActors returns an Actors that only knows about the built-in repository roles.
+func NewActors() *Actors {
+  a := &Actors{
+    names: make(map[string]map[int64]string),
+    ids:   make(map[string]map[string]int64),
+  }
+  for name, id := range builtinRoles {
+    a.Add("RepositoryRole", name, id)
+  }
+  return a
+}
+
+// Add registers the name of an actor. actorType is the bypass actor type; GitHub Apps are registered as Integration.
+func (a *Actors) Add(actorType, name string, id int64) {
+  if a.names[actorType] == nil {
+    a.names[actorType] = make(map[int64]string)
+    a.ids[actorType] = make(map[string]int64)
+  }
+  a.names[actorType][id] = name
+  a.ids[actorType][name] = id
+}
+
+// Load registers the teams, custom repository roles and installed GitHub Apps of the organization.
+func (a *Actors) Load(ctx context.Context, client *github.Client, owner string) error {
+  opts := &github.ListOptions{PerPage: 100}
+  for {
+    teams, resp, err := client.Teams.ListTeams(ctx, owner, opts)
+    if err != nil {
+      return err
+    }
+    for _, team := range teams {
+      a.Add("Team", team.GetSlug(), team.GetID())
+    }
+    if resp.NextPage == 0 {
+      break
+    }
+    opts.Page = resp.NextPage
+  }
+
+  roles, _, err := client.Organizations.ListCustomRepoRoles(ctx, owner)
+  if err != nil {
+    return err
+  }
+  for _, role := range roles.CustomRepoRoles {
+    a.Add("RepositoryRole", role.GetName(), role.GetID())
+  }
+
+  opts = &github.ListOptions{PerPage: 100}
+  for {
+    installations, resp, err := client.Organizations.ListInstallations(ctx, owner, opts)
+    if err != nil {
+      return err
+    }
+    for _, installation := range installations.Installations {
+      a.Add("Integration", installation.GetAppSlug(), installation.GetAppID())
+    }
+    if resp.NextPage == 0 {
+      break
+    }
+    opts.Page = resp.NextPage
+  }
+  return nil
+}
+
+// Name returns the name of the actor, or the ID as a string when the actor is unknown.
+func (a *Actors) Name(actorType string, id int64) (string, bool) {
+  if name, ok := a.names[actorType][id]; ok {
+    return name, true
+  }
+  return strconv.FormatInt(id, 10), false
+}
+
+// ID resolves a name or numeric ID of an actor.
+func (a *Actors) ID(actorType, name string) (int64, bool) {
+  if id, err := strconv.ParseInt(name, 10, 64); err == nil {
+    return id, true
+  }
+  id, ok := a.ids[actorType][name]
+  return id, ok
+}
@@ -0,0 +1,408 @@
+package rulesets
+
+import (
+  "bytes"
+  "encoding/json"
+  "fmt"
+  "sort"
+  "strings"
+
+  "github.com/cloudposse/terraform-example-module/internal/tfvars"
+)
+
+var refsPrefix = map[string]string{
+  "branch": "refs/heads/",
+  "tag":    "refs/tags/",
+}
+
+// Parameters of every rule the module supports, as named in the export
+var ruleParameters = map[string][]string{
+  "creation":         nil,
+  "deletion":         nil,
+  "non_fast_forward": nil,
+
+  "branch_name_pattern":         {"name", "negate", "operator", "pattern"},
+  "commit_author_email_pattern": {"name", "negate", "operator", "pattern"},
+  "commit_message_pattern":      {"name", "negate", "operator", "pattern"},
+  "committer_email_pattern":     {"name", "negate", "operator", "pattern"},
+  "tag_name_pattern":            {"name", "negate", "operator", "pattern"},
+
+  "merge_queue": {
+    "check_response_timeout_minutes",
+    "grouping_strategy",
+    "max_entries_to_build",
+    "max_entries_to_merge",
+    "merge_method",
+    "min_entries_to_merge",
+    "min_entries_to_merge_wait_minutes",
+  },
+  "pull_request": {
+    "dismiss_stale_reviews_on_push",
+    "require_code_owner_review",
+    "require_last_push_approval",
+    "required_approving_review_count",
+    "required_review_thread_resolution",
+  },
+  "required_deployments": {"required_deployment_environments"},
+  "required_status_checks": {
+    "do_not_enforce_on_create",
+    "required_status_checks",
+    "strict_required_status_checks_policy",
+  },
+}
+
+// ParseExports reads a ruleset export, which is either a single ruleset or a list of rulesets.
+func ParseExports(src []byte) ([]Export, error) {
+  src = bytes.TrimSpace(src)
+  if len(src) > 0 && src[0] == '[' {
+    var exports []Export
+    if err := json.Unmarshal(src, &exports); err != nil {
+      return nil, err
+    }
+    return exports, nil
+  }
+
+  var export Export
+  if err := json.Unmarshal(src, &export); err != nil {
+    return nil, err
+  }
+  return []Export{export}, nil
+}
+
+// ToModule converts exported rulesets to the rulesets input of the module, keyed by the sanitized ruleset name.
+// Anything that can not be expressed by the module is left out and reported in the returned warnings.
+func ToModule(exports []Export, actors *Actors) (map[string]Ruleset, []string) {
+  rulesets := make(map[string]Ruleset, len(exports))
+  var warnings []string
+
+  for _, export := range exports {
+    warn := func(format string, args ...interface{}) {
+      warnings = append(warnings, fmt.Sprintf("ruleset %q: ", export.Name)+fmt.Sprintf(format, args...))
+    }
+
+    prefix, ok := refsPrefix[export.Target]
+    if !ok {
+      warn("target %q is not supported, skipping", export.Target)
+      continue
+    }
+
+    ruleset := Ruleset{
+      Name:        export.Name,
+      Enforcement: export.Enforcement,
+      Target:      export.Target,
+    }
+
+    if ref := export.Conditions.RefName; ref != nil {
+      ruleset.Conditions.RefName.Include = trimRefs(ref.Include, prefix)
+      ruleset.Conditions.RefName.Exclude = trimRefs(ref.Exclude, prefix)
+    }
+    if ruleset.Conditions.RefName.Include == nil {
+      ruleset.Conditions.RefName.Include = []string{}
+    }
+    if ruleset.Conditions.RefName.Exclude == nil {
+      ruleset.Conditions.RefName.Exclude = []string{}
+    }
+    ruleset.Conditions.RepositoryName = export.Conditions.RepositoryName
+    if ruleset.Conditions.RepositoryName != nil {
+      warn("repository conditions are only supported by modules/organization-ruleset")
+    }
+    if export.Conditions.RepositoryProperty != nil {
+      warn("repository_property conditions are not supported by the GitHub provider yet, skipping")
+    }
+
+    for _, actor := range export.BypassActors {
+      if actor.BypassMode != "always" && actor.BypassMode != "pull_request" {
+        warn("bypass mode %q of %s actor is not supported, skipping", actor.BypassMode, actor.ActorType)
+        continue
+      }
+      bypass := BypassActor{
+        BypassMode: actor.BypassMode,
+        ActorType:  actor.ActorType,
+      }
+      switch actor.ActorType {
+      case "OrganizationAdmin", "DeployKey":
+      case "RepositoryRole", "Team", "Integration":
+        if actor.ActorID == nil {
+          warn("%s actor without an ID, skipping", actor.ActorType)
+          continue
+        }
+        name, ok := actors.Name(actor.ActorType, *actor.ActorID)
+        if !ok {
+          warn("%s actor %s is unknown, keeping the ID", actor.ActorType, name)
+        }
+        bypass.ActorID = name
+      default:
+        warn("bypass actor type %q is not supported, skipping", actor.ActorType)
+        continue
+      }
+      ruleset.BypassActors = append(ruleset.BypassActors, bypass)
+    }
+
+    for _, rule := range export.Rules {
+      known, ok := ruleParameters[rule.Type]
+      if !ok {
+        warn("rule %q is not supported, skipping", rule.Type)
+        continue
+      }
+      for _, name := range unknownParameters(rule.Parameters, known) {
+        warn("parameter %q of rule %q is not supported, skipping", name, rule.Type)
+      }
+      if err := setRule(&ruleset.Rules, rule, actors, warn); err != nil {
+        warn("rule %q: %s, skipping", rule.Type, err)
+      }
+    }
+
+    key := tfvars.Key(export.Name, "ruleset", func(k string) bool {
+      _, ok := rulesets[k]
+      return ok
+    })
+    rulesets[key] = ruleset
+  }
+
+  return rulesets, warnings
+}
+
+// FromModule converts the rulesets input of the module to rulesets that can be imported in the GitHub UI.
+// Every actor and status check integration must resolve to an ID.
+func FromModule(rulesets map[string]Ruleset, actors *Actors) (map[string]Export, error) {
+  exports := make(map[string]Export, len(rulesets))
+
+  for key, ruleset := range rulesets {
+    prefix, ok := refsPrefix[ruleset.Target]
+    if !ok {
+      return nil, fmt.Errorf("ruleset %s: unsupported target %q", key, ruleset.Target)
+    }
+
+    export := Export{
+      Name:        ruleset.Name,
+      Target:      ruleset.Target,
+      Enforcement: ruleset.Enforcement,
+      Conditions: ExportConditions{
+        RefName: &RefName{
+          Include: addRefs(ruleset.Conditions.RefName.Include, prefix),
+          Exclude: addRefs(ruleset.Conditions.RefName.Exclude, prefix),
+        },
+        RepositoryName:     ruleset.Conditions.RepositoryName,
+        RepositoryProperty: ruleset.Conditions.RepositoryProperty,
+      },
+      Rules:        []ExportRule{},
+      BypassActors: []ExportBypassActor{},
+    }
+
+    for _, actor := range ruleset.BypassActors {
+      bypass := ExportBypassActor{
+        ActorType:  actor.ActorType,
+        BypassMode: actor.BypassMode,
+      }
+      switch actor.ActorType {
+      case "OrganizationAdmin":
+        id := int64(1)
+        bypass.ActorID = &id
+      case "DeployKey":
+      default:
+        id, ok := actors.ID(actor.ActorType, actor.ActorID)
+        if !ok {
+          return nil, fmt.Errorf("ruleset %s: unknown %s actor %q", key, actor.ActorType, actor.ActorID)
+        }
+        bypass.ActorID = &id
+      }
+      export.BypassActors = append(export.BypassActors, bypass)
+    }
+
+    rules, err := exportRules(ruleset.Rules, actors)
+    if err != nil {
+      return nil, fmt.Errorf("ruleset %s: %w", key, err)
+    }
+    export.Rules = rules
+
+    exports[key] = export
+  }
+
+  return exports, nil
+}
+
+func setRule(rules *Rules, rule ExportRule, actors *Actors, warn func(string, ...interface{})) error {
+  params := rule.Parameters
+  if len(params) == 0 {
+    params = json.RawMessage("{}")
+  }
+
+  switch rule.Type {
+  case "creation":
+    rules.Creation = true
+  case "deletion":
+    rules.Deletion = true
+  case "non_fast_forward":
+    rules.NonFastForward = true
+  case "branch_name_pattern":
+    return json.Unmarshal(params, &rules.BranchNamePattern)
+  case "commit_author_email_pattern":
+    return json.Unmarshal(params, &rules.CommitAuthorEmailPattern)
+  case "commit_message_pattern":
+    return json.Unmarshal(params, &rules.CommitMessagePattern)
+  case "committer_email_pattern":
+    return json.Unmarshal(params, &rules.CommitterEmailPattern)
+  case "tag_name_pattern":
+    return json.Unmarshal(params, &rules.TagNamePattern)
+  case "merge_queue":
+    return json.Unmarshal(params, &rules.MergeQueue)
+  case "pull_request":
+    return json.Unmarshal(params, &rules.PullRequest)
+  case "required_deployments":
+    return json.Unmarshal(params, &rules.RequiredDeployments)
+  case "required_status_checks":
+    var checks exportStatusChecks
+    if err := json.Unmarshal(params, &checks); err != nil {
+      return err
+    }
+    rules.RequiredStatusChecks = &RequiredStatusChecks{
+      RequiredCheck:                    []StatusCheck{},
+      StrictRequiredStatusChecksPolicy: checks.StrictRequiredStatusChecksPolicy,
+      DoNotEnforceOnCreate:             checks.DoNotEnforceOnCreate,
+    }
+    for _, check := range checks.RequiredStatusChecks {
+      statusCheck := StatusCheck{Context: check.Context}
+      if check.IntegrationID != nil {
+        name, ok := actors.Name("Integration", *check.IntegrationID)
+        if !ok {
+          warn("integration %s of status check %q is unknown, keeping the ID", name, check.Context)
+        }
+        statusCheck.IntegrationID = name
+      }
+      rules.RequiredStatusChecks.RequiredCheck = append(rules.RequiredStatusChecks.RequiredCheck, statusCheck)
+    }
+  }
+  return nil
+}
+
+func exportRules(rules Rules, actors *Actors) ([]ExportRule, error) {
+  var out []ExportRule
+  add := func(ruleType string, params interface{}) error {
+    rule := ExportRule{Type: ruleType}
+    if params != nil {
+      raw, err := json.Marshal(params)
+      if err != nil {
+        return err
+      }
+      rule.Parameters = raw
+    }
+    out = append(out, rule)
+    return nil
+  }
+
+  if rules.Creation {
+    add("creation", nil)
+  }
+  if rules.Deletion {
+    add("deletion", nil)
+  }
+  if rules.NonFastForward {
+    add("non_fast_forward", nil)
+  }
+
+  patterns := []struct {
+    ruleType string
+    pattern  *Pattern
+  }{
+    {"branch_name_pattern", rules.BranchNamePattern},
+    {"commit_author_email_pattern", rules.CommitAuthorEmailPattern},
+    {"commit_message_pattern", rules.CommitMessagePattern},
+    {"committer_email_pattern", rules.CommitterEmailPattern},
+    {"tag_name_pattern", rules.TagNamePattern},
+  }
+  for _, p := range patterns {
+    if p.pattern != nil {
+      if err := add(p.ruleType, p.pattern); err != nil {
+        return nil, err
+      }
+    }
+  }
+
+  if rules.MergeQueue != nil {
+    if err := add("merge_queue", rules.MergeQueue); err != nil {
+      return nil, err
+    }
+  }
+  if rules.PullRequest != nil {
+    if err := add("pull_request", rules.PullRequest); err != nil {
+      return nil, err
+    }
+  }
+  if rules.RequiredDeployments != nil {
+    if err := add("required_deployments", rules.RequiredDeployments); err != nil {
+      return nil, err
+    }
+  }
+  if checks := rules.RequiredStatusChecks; checks != nil {
+    params := exportStatusChecks{
+      RequiredStatusChecks:             []exportStatusCheck{},
+      StrictRequiredStatusChecksPolicy: checks.StrictRequiredStatusChecksPolicy,
+      DoNotEnforceOnCreate:             checks.DoNotEnforceOnCreate,
+    }
+    for _, check := range checks.RequiredCheck {
+      statusCheck := exportStatusCheck{Context: check.Context}
+      if check.IntegrationID != "" {
+        id, ok := actors.ID("Integration", check.IntegrationID)
+        if !ok {
+          return nil, fmt.Errorf("unknown integration %q of status check %q", check.IntegrationID, check.Context)
+        }
+        statusCheck.IntegrationID = &id
+      }
+      params.RequiredStatusChecks = append(params.RequiredStatusChecks, statusCheck)
+    }
+    if err := add("required_status_checks", params); err != nil {
+      return nil, err
+    }
+  }
+
+  if out == nil {
+    out = []ExportRule{}
+  }
+  return out, nil
+}
+
+func unknownParameters(params json.RawMessage, known []string) []string {
+  var fields map[string]json.RawMessage
+  if len(params) == 0 || json.Unmarshal(params, &fields) != nil {
+    return nil
+  }
+
+  var unknown []string
+  for name := range fields {
+    found := false
+    for _, k := range known {
+      if k == name {
+        found = true
+        break
+      }
+    }
+    if !found {
+      unknown = append(unknown, name)
+    }
+  }
+  sort.Strings(unknown)
+  return unknown
+}
+
+func trimRefs(refs []string, prefix string) []string {
+  if refs == nil {
+    return nil
+  }
+  out := make([]string, 0, len(refs))
+  for _, ref := range refs {
+    out = append(out, strings.TrimPrefix(ref, prefix))
+  }
+  return out
+}
+
+func addRefs(refs []string, prefix string) []string {
+  out := make([]string, 0, len(refs))
+  for _, ref := range refs {
+    if ref == "~DEFAULT_BRANCH" || ref == "~ALL" || strings.HasPrefix(ref, prefix) {
+      out = append(out, ref)
+    } else {
+      out = append(out, prefix+ref)
+    }
+  }
+  return out
+}
@@ -0,0 +1,175 @@
+package rulesets
+
+import (
+  "os"
+  "testing"
+
+  "github.com/stretchr/testify/assert"
+  "github.com/stretchr/testify/require"
+)
+
+func testActors() *Actors {
+  actors := NewActors()
+  actors.Add("Team", "test-team", 1234)
+  actors.Add("Integration", "github-actions", 15368)
+  return actors
+}
+
+// Test the conversion of a ruleset exported from the GitHub UI to the module input.
+func TestToModule(t *testing.T) {
+  src, err := os.ReadFile("testdata/export.json")
+  require.NoError(t, err)
+
+  exports, err := ParseExports(src)
+  require.NoError(t, err)
+  require.Equal(t, 1, len(exports))
+
+  rulesets, warnings := ToModule(exports, testActors())
+  require.Equal(t, 1, len(rulesets))
+
+  ruleset, ok := rulesets["default_branch_protection"]
+  require.True(t, ok)
+
+  assert.Equal(t, "Default branch protection", ruleset.Name)
+  assert.Equal(t, "active", ruleset.Enforcement)
+  assert.Equal(t, "branch", ruleset.Target)
+  assert.Equal(t, []string{"~DEFAULT_BRANCH", "main"}, ruleset.Conditions.RefName.Include)
+  assert.Equal(t, []string{"release/*"}, ruleset.Conditions.RefName.Exclude)
+
+  assert.Equal(t, []BypassActor{
+    {BypassMode: "always", ActorID: "admin", ActorType: "RepositoryRole"},
+    {BypassMode: "pull_request", ActorID: "test-team", ActorType: "Team"},
+    {BypassMode: "always", ActorType: "OrganizationAdmin"},
+    {BypassMode: "always", ActorType: "DeployKey"},
+  }, ruleset.BypassActors)
+
+  assert.True(t, ruleset.Rules.Deletion)
+  assert.True(t, ruleset.Rules.NonFastForward)
+  assert.False(t, ruleset.Rules.Creation)
+  assert.Equal(t, &PullRequest{
+    DismissStaleReviewsOnPush:      true,
+    RequireCodeOwnerReview:         true,
+    RequiredApprovingReviewCount:   2,
+    RequiredReviewThreadResolution: true,
+  }, ruleset.Rules.PullRequest)
+  assert.Equal(t, &RequiredStatusChecks{
+    RequiredCheck: []StatusCheck{
+      {Context: "test", IntegrationID: "github-actions"},
+      {Context: "lint"},
+    },
+    StrictRequiredStatusChecksPolicy: true,
+  }, ruleset.Rules.RequiredStatusChecks)
+
+  assert.ElementsMatch(t, []string{
+    `ruleset "Default branch protection": bypass mode "exempt" of Integration actor is not supported, skipping`,
+    `ruleset "Default branch protection": rule "required_linear_history" is not supported, skipping`,
+    `ruleset "Default branch protection": parameter "allowed_merge_methods" of rule "pull_request" is not supported, skipping`,
+  }, warnings)
+}
+
+// Test that unknown actors are kept as IDs and duplicate names get unique keys.
+func TestToModuleUnknownActors(t *testing.T) {
+  teamID := int64(4321)
+  exports := []Export{
+    {
+      Name:        "1st ruleset",
+      Target:      "tag",
+      Enforcement: "active",
+      Conditions:  ExportConditions{RefName: &RefName{Include: []string{"refs/tags/v*"}}},
+      BypassActors: []ExportBypassActor{
+        {ActorID: &teamID, ActorType: "Team", BypassMode: "always"},
+      },
+    },
+    {
+      Name:        "1st ruleset",
+      Target:      "tag",
+      Enforcement: "disabled",
+    },
+    {
+      Name:        "Push rules",
+      Target:      "push",
+      Enforcement: "active",
+    },
+  }
+
+  rulesets, warnings := ToModule(exports, NewActors())
+  require.Equal(t, 2, len(rulesets))
+
+  assert.Equal(t, []string{"v*"}, rulesets["ruleset_1st_ruleset"].Conditions.RefName.Include)
+  assert.Equal(t, "4321", rulesets["ruleset_1st_ruleset"].BypassActors[0].ActorID)
+  assert.Equal(t, "disabled", rulesets["ruleset_1st_ruleset_2"].Enforcement)
+
+  assert.Equal(t, []string{
+    `ruleset "1st ruleset": Team actor 4321 is unknown, keeping the ID`,
+    `ruleset "Push rules": target "push" is not supported, skipping`,
+  }, warnings)
+}
+
+// Test that rulesets in evaluate mode keep their enforcement, which the module supports.
+func TestToModuleEvaluate(t *testing.T) {
+  exports := []Export{{Name: "Trial", Target: "branch", Enforcement: "evaluate"}}
+
+  rulesets, warnings := ToModule(exports, NewActors())
+  assert.Equal(t, "evaluate", rulesets["trial"].Enforcement)
+  assert.Empty(t, warnings)
+}
+
+// Test that repository_property conditions are left out, as the provider does not support them.
+func TestToModuleRepositoryProperty(t *testing.T) {
+  exports := []Export{{
+    Name:        "Baseline",
+    Target:      "branch",
+    Enforcement: "active",
+    Conditions: ExportConditions{
+      RepositoryProperty: &RepositoryProperty{},
+    },
+  }}
+
+  rulesets, warnings := ToModule(exports, NewActors())
+  assert.Nil(t, rulesets["baseline"].Conditions.RepositoryProperty)
+  assert.Equal(t, []string{`ruleset "Baseline": repository_property conditions are not supported by the GitHub provider yet, skipping`}, warnings)
+}
+
+// Test that converting the module input back to an export keeps the supported parts of the ruleset.
+func TestFromModuleRoundTrip(t *testing.T) {
+  src, err := os.ReadFile("testdata/export.json")
+  require.NoError(t, err)
+
+  exports, err := ParseExports(src)
+  require.NoError(t, err)
+
+  actors := testActors()
+  rulesets, _ := ToModule(exports, actors)
+
+  converted, err := FromModule(rulesets, actors)
+  require.NoError(t, err)
+
+  export := converted["default_branch_protection"]
+  assert.Equal(t, exports[0].Name, export.Name)
+  assert.Equal(t, exports[0].Target, export.Target)
+  assert.Equal(t, exports[0].Enforcement, export.Enforcement)
+  assert.Equal(t, exports[0].Conditions, export.Conditions)
+
+  // The bypass actor with an unsupported mode is dropped
+  assert.Equal(t, exports[0].BypassActors[:4], export.BypassActors)
+
+  rulesBack, _ := ToModule([]Export{export}, actors)
+  assert.Equal(t, rulesets, rulesBack)
+}
+
+// Test that actors which can not be resolved are refused.
+func TestFromModuleUnknownActor(t *testing.T) {
+  rulesets := map[string]Ruleset{
+    "default": {
+      Name:        "Default",
+      Enforcement: "active",
+      Target:      "branch",
+      BypassActors: []BypassActor{
+        {BypassMode: "always", ActorID: "unknown-team", ActorType: "Team"},
+      },
+    },
+  }
+
+  _, err := FromModule(rulesets, NewActors())
+  assert.EqualError(t, err, `ruleset default: unknown Team actor "unknown-team"`)
+}
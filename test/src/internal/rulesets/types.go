@@ -0,0 +1,151 @@
+// Package rulesets converts between GitHub ruleset JSON exports and the rulesets input of the module.
+package rulesets
+
+import (
+  "encoding/json"
+)
+
+// Ruleset is an entry of the rulesets input of the module and of modules/organization-ruleset.
+type Ruleset struct {
+  Name         string        `json:"name"`
+  Enforcement  string        `json:"enforcement"`
+  Target       string        `json:"target"`
+  BypassActors []BypassActor `json:"bypass_actors,omitempty"`
+  Conditions   Conditions    `json:"conditions"`
+  Rules        Rules         `json:"rules"`
+}
+
+type BypassActor struct {
+  BypassMode string `json:"bypass_mode"`
+  ActorID    string `json:"actor_id,omitempty"`
+  ActorType  string `json:"actor_type"`
+}
+
+type Conditions struct {
+  RefName RefName `json:"ref_name"`
+  // Organization rulesets only
+  RepositoryName     *RepositoryName     `json:"repository_name,omitempty"`
+  RepositoryProperty *RepositoryProperty `json:"repository_property,omitempty"`
+}
+
+type RefName struct {
+  Include []string `json:"include"`
+  Exclude []string `json:"exclude"`
+}
+
+type RepositoryName struct {
+  Include   []string `json:"include"`
+  Exclude   []string `json:"exclude"`
+  Protected bool     `json:"protected,omitempty"`
+}
+
+type RepositoryProperty struct {
+  Include []PropertyTarget `json:"include"`
+  Exclude []PropertyTarget `json:"exclude"`
+}
+
+type PropertyTarget struct {
+  Name           string   `json:"name"`
+  PropertyValues []string `json:"property_values"`
+  Source         string   `json:"source,omitempty"`
+}
+
+type Rules struct {
+  Creation       bool `json:"creation,omitempty"`
+  Deletion       bool `json:"deletion,omitempty"`
+  NonFastForward bool `json:"non_fast_forward,omitempty"`
+
+  BranchNamePattern        *Pattern `json:"branch_name_pattern,omitempty"`
+  CommitAuthorEmailPattern *Pattern `json:"commit_author_email_pattern,omitempty"`
+  CommitMessagePattern     *Pattern `json:"commit_message_pattern,omitempty"`
+  CommitterEmailPattern    *Pattern `json:"committer_email_pattern,omitempty"`
+  TagNamePattern           *Pattern `json:"tag_name_pattern,omitempty"`
+
+  MergeQueue           *MergeQueue           `json:"merge_queue,omitempty"`
+  PullRequest          *PullRequest          `json:"pull_request,omitempty"`
+  RequiredDeployments  *RequiredDeployments  `json:"required_deployments,omitempty"`
+  RequiredStatusChecks *RequiredStatusChecks `json:"required_status_checks,omitempty"`
+}
+
+type Pattern struct {
+  Operator string `json:"operator"`
+  Pattern  string `json:"pattern"`
+  Name     string `json:"name,omitempty"`
+  Negate   bool   `json:"negate,omitempty"`
+}
+
+type MergeQueue struct {
+  CheckResponseTimeoutMinutes  int    `json:"check_response_timeout_minutes"`
+  GroupingStrategy             string `json:"grouping_strategy"`
+  MaxEntriesToBuild            int    `json:"max_entries_to_build"`
+  MaxEntriesToMerge            int    `json:"max_entries_to_merge"`
+  MergeMethod                  string `json:"merge_method"`
+  MinEntriesToMerge            int    `json:"min_entries_to_merge"`
+  MinEntriesToMergeWaitMinutes int    `json:"min_entries_to_merge_wait_minutes"`
+}
+
+type PullRequest struct {
+  DismissStaleReviewsOnPush      bool `json:"dismiss_stale_reviews_on_push"`
+  RequireCodeOwnerReview         bool `json:"require_code_owner_review"`
+  RequireLastPushApproval        bool `json:"require_last_push_approval"`
+  RequiredApprovingReviewCount   int  `json:"required_approving_review_count"`
+  RequiredReviewThreadResolution bool `json:"required_review_thread_resolution"`
+}
+
+type RequiredDeployments struct {
+  RequiredDeploymentEnvironments []string `json:"required_deployment_environments"`
+}
+
+type RequiredStatusChecks struct {
+  RequiredCheck                    []StatusCheck `json:"required_check"`
+  StrictRequiredStatusChecksPolicy bool          `json:"strict_required_status_checks_policy"`
+  DoNotEnforceOnCreate             bool          `json:"do_not_enforce_on_create,omitempty"`
+}
+
+type StatusCheck struct {
+  Context string `json:"context"`
+  // GitHub App slug or ID
+  IntegrationID string `json:"integration_id,omitempty"`
+}
+
+// Export is a ruleset as exported from and imported into the GitHub UI.
+type Export struct {
+  ID           int64               `json:"id,omitempty"`
+  Name         string              `json:"name"`
+  Target       string              `json:"target"`
+  SourceType   string              `json:"source_type,omitempty"`
+  Source       string              `json:"source,omitempty"`
+  Enforcement  string              `json:"enforcement"`
+  Conditions   ExportConditions    `json:"conditions"`
+  Rules        []ExportRule        `json:"rules"`
+  BypassActors []ExportBypassActor `json:"bypass_actors"`
+}
+
+type ExportConditions struct {
+  RefName            *RefName            `json:"ref_name,omitempty"`
+  RepositoryName     *RepositoryName     `json:"repository_name,omitempty"`
+  RepositoryProperty *RepositoryProperty `json:"repository_property,omitempty"`
+}
+
+type ExportRule struct {
+  Type       string          `json:"type"`
+  Parameters json.RawMessage `json:"parameters,omitempty"`
+}
+
+type ExportBypassActor struct {
+  // Null for DeployKey actors
+  ActorID    *int64 `json:"actor_id"`
+  ActorType  string `json:"actor_type"`
+  BypassMode string `json:"bypass_mode"`
+}
+
+type exportStatusChecks struct {
+  RequiredStatusChecks             []exportStatusCheck `json:"required_status_checks"`
+  StrictRequiredStatusChecksPolicy bool                `json:"strict_required_status_checks_policy"`
+  DoNotEnforceOnCreate             bool                `json:"do_not_enforce_on_create,omitempty"`
+}
+
+type exportStatusCheck struct {
+  Context       string `json:"context"`
+  IntegrationID *int64 `json:"integration_id,omitempty"`
+}
@@ -0,0 +1,428 @@
+// Package schema generates a JSON Schema for the inputs of a Terraform module from its variable declarations.
+//
+// Types, including optional() attributes and their defaults, are converted as is. Validations are converted when
+// they are one of the forms used by the modules of this repository:
+//
+//   contains(["a", "b"], var.x)
+//   can(regex("^pattern$", var.x))
+//   var.x == null || <validation>
+//   try(<validation>, true)
+//   alltrue([for k, v in var.x : <validation of k or v>])
+//
+// Other validations are left to Terraform, so the schema accepts a superset of the valid inputs. Objects are the
+// exception, as attributes that are not declared are refused while Terraform silently drops them.
+package schema
+
+import (
+  "encoding/json"
+  "fmt"
+  "sort"
+
+  "github.com/hashicorp/hcl/v2"
+  "github.com/hashicorp/hcl/v2/hclparse"
+  "github.com/hashicorp/hcl/v2/hclsyntax"
+  "github.com/zclconf/go-cty/cty"
+  ctyjson "github.com/zclconf/go-cty/cty/json"
+)
+
+const Draft = "https://json-schema.org/draft/2020-12/schema"
+
+// Schema is the subset of JSON Schema used for module inputs.
+type Schema struct {
+  Schema               string             `json:"$schema,omitempty"`
+  Title                string             `json:"title,omitempty"`
+  Description          string             `json:"description,omitempty"`
+  Type                 []string           `json:"type,omitempty"`
+  Properties           map[string]*Schema `json:"properties,omitempty"`
+  Required             []string           `json:"required,omitempty"`
+  AdditionalProperties interface{}        `json:"additionalProperties,omitempty"`
+  PropertyNames        *Schema            `json:"propertyNames,omitempty"`
+  Items                interface{}        `json:"items,omitempty"`
+  PrefixItems          []*Schema          `json:"prefixItems,omitempty"`
+  UniqueItems          bool               `json:"uniqueItems,omitempty"`
+  Enum                 []interface{}      `json:"enum,omitempty"`
+  Pattern              string             `json:"pattern,omitempty"`
+  Default              json.RawMessage    `json:"default,omitempty"`
+
+  // Set for optional attributes with a default, which replaces null values
+  nullDefault bool
+}
+
+// Generate returns the schema of the variables declared in the given files.
+func Generate(title string, files []string) (*Schema, error) {
+  root := &Schema{
+    Schema:               Draft,
+    Title:                title,
+    Type:                 []string{"object"},
+    Properties:           make(map[string]*Schema),
+    AdditionalProperties: false,
+  }
+
+  parser := hclparse.NewParser()
+  var variables []*hclsyntax.Block
+  for _, filename := range files {
+    file, diags := parser.ParseHCLFile(filename)
+    if diags.HasErrors() {
+      return nil, diags
+    }
+    for _, block := range file.Body.(*hclsyntax.Body).Blocks {
+      if block.Type == "variable" {
+        variables = append(variables, block)
+      }
+    }
+  }
+
+  for _, block := range variables {
+    name := block.Labels[0]
+    s, err := variableSchema(block)
+    if err != nil {
+      return nil, fmt.Errorf("%s: variable %s: %w", block.DefRange(), name, err)
+    }
+    root.Properties[name] = s
+
+    if _, ok := block.Body.Attributes["default"]; !ok {
+      root.Required = append(root.Required, name)
+    }
+  }
+  sort.Strings(root.Required)
+
+  // Validations may refer to other variables, so they are applied once all variables are known
+  for _, block := range variables {
+    for _, validation := range block.Body.Blocks {
+      if validation.Type != "validation" {
+        continue
+      }
+      if attr, ok := validation.Body.Attributes["condition"]; ok {
+        applyValidation(root, attr.Expr, nil, false)
+      }
+    }
+  }
+
+  return root, nil
+}
+
+func variableSchema(block *hclsyntax.Block) (*Schema, error) {
+  s := &Schema{}
+  if attr, ok := block.Body.Attributes["type"]; ok {
+    var err error
+    if s, err = typeSchema(attr.Expr); err != nil {
+      return nil, err
+    }
+  }
+
+  if attr, ok := block.Body.Attributes["description"]; ok {
+    if val, diags := attr.Expr.Value(nil); !diags.HasErrors() && val.Type() == cty.String {
+      s.Description = val.AsString()
+    }
+  }
+
+  nullable := true
+  if attr, ok := block.Body.Attributes["nullable"]; ok {
+    val, diags := attr.Expr.Value(nil)
+    if diags.HasErrors() {
+      return nil, diags
+    }
+    nullable = val.True()
+  }
+  if nullable {
+    s.allowNull()
+  }
+
+  if attr, ok := block.Body.Attributes["default"]; ok {
+    val, diags := attr.Expr.Value(nil)
+    if diags.HasErrors() {
+      return nil, diags
+    }
+    if !val.IsNull() {
+      out, err := ctyjson.Marshal(val, val.Type())
+      if err != nil {
+        return nil, err
+      }
+      s.Default = out
+    }
+  }
+  return s, nil
+}
+
+// typeSchema converts a type constraint.
+func typeSchema(expr hclsyntax.Expression) (*Schema, error) {
+  if keyword := hcl.ExprAsKeyword(expr); keyword != "" {
+    switch keyword {
+    case "string":
+      return &Schema{Type: []string{"string"}}, nil
+    case "number":
+      return &Schema{Type: []string{"number"}}, nil
+    case "bool":
+      return &Schema{Type: []string{"boolean"}}, nil
+    case "any":
+      return &Schema{}, nil
+    }
+    return nil, fmt.Errorf("%s: unsupported type %s", expr.Range(), keyword)
+  }
+
+  call, ok := expr.(*hclsyntax.FunctionCallExpr)
+  if !ok || len(call.Args) == 0 {
+    return nil, fmt.Errorf("%s: unsupported type constraint", expr.Range())
+  }
+
+  switch call.Name {
+  case "list", "set":
+    items, err := typeSchema(call.Args[0])
+    if err != nil {
+      return nil, err
+    }
+    return &Schema{Type: []string{"array"}, Items: items, UniqueItems: call.Name == "set"}, nil
+  case "map":
+    values, err := typeSchema(call.Args[0])
+    if err != nil {
+      return nil, err
+    }
+    return &Schema{Type: []string{"object"}, AdditionalProperties: values}, nil
+  case "tuple":
+    tuple, ok := call.Args[0].(*hclsyntax.TupleConsExpr)
+    if !ok {
+      return nil, fmt.Errorf("%s: unsupported tuple type", expr.Range())
+    }
+    s := &Schema{Type: []string{"array"}, Items: false}
+    for _, item := range tuple.Exprs {
+      items, err := typeSchema(item)
+      if err != nil {
+        return nil, err
+      }
+      s.PrefixItems = append(s.PrefixItems, items)
+    }
+    return s, nil
+  case "object":
+    object, ok := call.Args[0].(*hclsyntax.ObjectConsExpr)
+    if !ok {
+      return nil, fmt.Errorf("%s: unsupported object type", expr.Range())
+    }
+    s := &Schema{
+      Type:                 []string{"object"},
+      Properties:           make(map[string]*Schema),
+      AdditionalProperties: false,
+    }
+    for _, item := range object.Items {
+      name := hcl.ExprAsKeyword(item.KeyExpr)
+      attr, optional, err := attributeSchema(item.ValueExpr)
+      if err != nil {
+        return nil, err
+      }
+      s.Properties[name] = attr
+      if !optional {
+        s.Required = append(s.Required, name)
+      }
+    }
+    sort.Strings(s.Required)
+    return s, nil
+  }
+  return nil, fmt.Errorf("%s: unsupported type %s", expr.Range(), call.Name)
+}
+
+// attributeSchema converts the type of an object attribute, which may be optional with a default.
+func attributeSchema(expr hclsyntax.Expression) (*Schema, bool, error) {
+  call, ok := expr.(*hclsyntax.FunctionCallExpr)
+  if !ok || call.Name != "optional" {
+    s, err := typeSchema(expr)
+    return s, false, err
+  }
+
+  s, err := typeSchema(call.Args[0])
+  if err != nil {
+    return nil, true, err
+  }
+  // Optional attributes are null when not set, unless they have a default
+  s.allowNull()
+  if len(call.Args) > 1 {
+    val, diags := call.Args[1].Value(nil)
+    if diags.HasErrors() {
+      return nil, true, diags
+    }
+    if !val.IsNull() {
+      out, err := ctyjson.Marshal(val, val.Type())
+      if err != nil {
+        return nil, true, err
+      }
+      s.Default = out
+      s.nullDefault = true
+    }
+  }
+  return s, true, nil
+}
+
+func (s *Schema) allowNull() {
+  if len(s.Type) > 0 && !s.isNullable() {
+    s.Type = append(s.Type, "null")
+  }
+}
+
+func (s *Schema) isNullable() bool {
+  for _, t := range s.Type {
+    if t == "null" {
+      return true
+    }
+  }
+  return false
+}
+
+// scope binds the symbols of for expressions to the schema of the keys or values they iterate over.
+type scope struct {
+  parent *scope
+  name   string
+  schema *Schema
+  key    bool
+}
+
+func (sc *scope) lookup(name string) (*scope, bool) {
+  for s := sc; s != nil; s = s.parent {
+    if s.name == name {
+      return s, true
+    }
+  }
+  return nil, false
+}
+
+// applyValidation adds the constraints of a validation condition. nullable is set when the condition allows the
+// value it constrains to be null.
+func applyValidation(root *Schema, expr hclsyntax.Expression, sc *scope, nullable bool) {
+  switch e := expr.(type) {
+  case *hclsyntax.ParenthesesExpr:
+    applyValidation(root, e.Expression, sc, nullable)
+  case *hclsyntax.BinaryOpExpr:
+    // x == null || condition
+    if e.Op == hclsyntax.OpLogicalOr && isNullCheck(e.LHS) {
+      applyValidation(root, e.RHS, sc, true)
+    }
+  case *hclsyntax.FunctionCallExpr:
+    switch {
+    case e.Name == "try" && len(e.Args) == 2:
+      // try(condition, true) passes when the value is not set
+      if val, diags := e.Args[1].Value(nil); !diags.HasErrors() && val.RawEquals(cty.True) {
+        applyValidation(root, e.Args[0], sc, nullable)
+      }
+    case e.Name == "contains" && len(e.Args) == 2:
+      values, ok := literalList(e.Args[0])
+      if !ok {
+        return
+      }
+      if s, key := resolve(root, e.Args[1], sc); s != nil && !key {
+        // Null optional attributes are replaced by their default before validation
+        if nullable || s.nullDefault {
+          values = append(values, nil)
+        }
+        s.Enum = values
+      }
+    case e.Name == "can" && len(e.Args) == 1:
+      call, ok := e.Args[0].(*hclsyntax.FunctionCallExpr)
+      if !ok || call.Name != "regex" || len(call.Args) != 2 {
+        return
+      }
+      pattern, diags := call.Args[0].Value(nil)
+      if diags.HasErrors() || pattern.Type() != cty.String {
+        return
+      }
+      s, key := resolve(root, call.Args[1], sc)
+      switch {
+      case s == nil:
+      case key:
+        s.PropertyNames = &Schema{Pattern: pattern.AsString()}
+      default:
+        s.Pattern = pattern.AsString()
+      }
+    case e.Name == "alltrue" && len(e.Args) == 1:
+      loop, ok := e.Args[0].(*hclsyntax.ForExpr)
+      if !ok || loop.CondExpr != nil {
+        return
+      }
+      collection, key := resolve(root, loop.CollExpr, sc)
+      if collection == nil || key {
+        return
+      }
+      inner := sc
+      if loop.KeyVar != "" {
+        inner = &scope{parent: inner, name: loop.KeyVar, schema: collection, key: true}
+      }
+      if element := elementSchema(collection); element != nil {
+        inner = &scope{parent: inner, name: loop.ValVar, schema: element}
+      }
+      applyValidation(root, loop.ValExpr, inner, false)
+    }
+  }
+}
+
+// resolve returns the schema of the value an expression refers to, and whether the expression refers to the keys
+// of that value rather than the value itself.
+func resolve(root *Schema, expr hclsyntax.Expression, sc *scope) (*Schema, bool) {
+  if call, ok := expr.(*hclsyntax.FunctionCallExpr); ok && call.Name == "try" && len(call.Args) > 0 {
+    return resolve(root, call.Args[0], sc)
+  }
+
+  traversal, ok := expr.(*hclsyntax.ScopeTraversalExpr)
+  if !ok {
+    return nil, false
+  }
+
+  var s *Schema
+  steps := traversal.Traversal[1:]
+  if traversal.Traversal.RootName() == "var" {
+    if len(steps) == 0 {
+      return nil, false
+    }
+    attr, ok := steps[0].(hcl.TraverseAttr)
+    if !ok {
+      return nil, false
+    }
+    s = root.Properties[attr.Name]
+    steps = steps[1:]
+  } else if bound, ok := sc.lookup(traversal.Traversal.RootName()); ok {
+    if bound.key {
+      return bound.schema, len(steps) == 0
+    }
+    s = bound.schema
+  }
+
+  for _, step := range steps {
+    attr, ok := step.(hcl.TraverseAttr)
+    if s == nil || !ok {
+      return nil, false
+    }
+    s = s.Properties[attr.Name]
+  }
+  return s, false
+}
+
+// elementSchema returns the schema of the values of a map or the items of a list or set.
+func elementSchema(s *Schema) *Schema {
+  if items, ok := s.Items.(*Schema); ok {
+    return items
+  }
+  if values, ok := s.AdditionalProperties.(*Schema); ok {
+    return values
+  }
+  return nil
+}
+
+func isNullCheck(expr hclsyntax.Expression) bool {
+  e, ok := expr.(*hclsyntax.BinaryOpExpr)
+  if !ok || e.Op != hclsyntax.OpEqual {
+    return false
+  }
+  literal, ok := e.RHS.(*hclsyntax.LiteralValueExpr)
+  return ok && literal.Val.IsNull()
+}
+
+func literalList(expr hclsyntax.Expression) ([]interface{}, bool) {
+  tuple, ok := expr.(*hclsyntax.TupleConsExpr)
+  if !ok {
+    return nil, false
+  }
+  var values []interface{}
+  for _, item := range tuple.Exprs {
+    val, diags := item.Value(nil)
+    if diags.HasErrors() || val.Type() != cty.String {
+      return nil, false
+    }
+    values = append(values, val.AsString())
+  }
+  return values, true
+}
@@ -0,0 +1,101 @@
+package schema
+
+import (
+  "encoding/json"
+  "testing"
+
+  "github.com/stretchr/testify/assert"
+  "github.com/stretchr/testify/require"
+)
+
+// Test the schema generated from the types, defaults and validations of variables.
+func TestGenerate(t *testing.T) {
+  s, err := Generate("example", []string{"testdata/variables.tf"})
+  require.NoError(t, err)
+
+  assert.Equal(t, Draft, s.Schema)
+  assert.Equal(t, "example", s.Title)
+  assert.Equal(t, []string{"name"}, s.Required)
+  assert.Equal(t, false, s.AdditionalProperties)
+
+  name := s.Properties["name"]
+  assert.Equal(t, []string{"string", "null"}, name.Type)
+  assert.Equal(t, "Name of the repository", name.Description)
+  assert.Equal(t, "^[a-z-]+$", name.Pattern)
+
+  visibility := s.Properties["visibility"]
+  assert.Equal(t, []string{"string"}, visibility.Type)
+  assert.Equal(t, []interface{}{"public", "private"}, visibility.Enum)
+  assert.JSONEq(t, `"public"`, string(visibility.Default))
+
+  topics := s.Properties["topics"]
+  assert.Equal(t, []string{"array", "null"}, topics.Type)
+  assert.True(t, topics.UniqueItems)
+
+  scanning := s.Properties["scanning"]
+  assert.Equal(t, []string{"object", "null"}, scanning.Type)
+  assert.Equal(t, []interface{}{"configured", "not-configured", nil}, scanning.Properties["state"].Enum)
+  assert.JSONEq(t, `"configured"`, string(scanning.Properties["state"].Default))
+  assert.Equal(t, []string{"array", "null"}, scanning.Properties["languages"].Type)
+
+  rulesets := s.Properties["rulesets"]
+  assert.Equal(t, []string{"object"}, rulesets.Type)
+  assert.Equal(t, "^[a-z_]+$", rulesets.PropertyNames.Pattern)
+
+  ruleset := rulesets.AdditionalProperties.(*Schema)
+  assert.Equal(t, []string{"rules", "target"}, ruleset.Required)
+  assert.Equal(t, []interface{}{"branch", "tag"}, ruleset.Properties["target"].Enum)
+  assert.Equal(t, []interface{}{"always", "pull_request"}, ruleset.Properties["bypass_actors"].Items.(*Schema).Properties["bypass_mode"].Enum)
+  assert.Equal(t, []interface{}{"starts_with", "regex"}, ruleset.Properties["rules"].Properties["pattern"].Properties["operator"].Enum)
+}
+
+// Test the validation of inputs against the generated schema.
+func TestValidate(t *testing.T) {
+  s, err := Generate("example", []string{"testdata/variables.tf"})
+  require.NoError(t, err)
+
+  for _, tc := range []struct {
+    name  string
+    vars  string
+    valid bool
+  }{
+    {"minimal", `{"name": "example"}`, true},
+    {"complete", `{
+      "name": "example",
+      "visibility": "private",
+      "topics": ["terraform"],
+      "scanning": {"state": "not-configured", "languages": ["go"]},
+      "rulesets": {
+        "main": {
+          "target": "branch",
+          "bypass_actors": [{"bypass_mode": "always", "actor_id": "admin"}],
+          "rules": {"deletion": true, "pattern": {"operator": "regex"}}
+        }
+      }
+    }`, true},
+    {"missing required", `{}`, false},
+    {"undeclared variable", `{"name": "example", "description": "example"}`, false},
+    {"pattern", `{"name": "Example"}`, false},
+    {"enum", `{"name": "example", "visibility": "internal"}`, false},
+    {"not nullable", `{"name": "example", "visibility": null}`, false},
+    {"duplicate set items", `{"name": "example", "topics": ["go", "go"]}`, false},
+    {"nested enum", `{"name": "example", "scanning": {"state": "enabled"}}`, false},
+    {"property names", `{"name": "example", "rulesets": {"Main": {"target": "branch", "rules": {}}}}`, false},
+    {"undeclared attribute", `{"name": "example", "rulesets": {"main": {"target": "branch", "rules": {"creation": true}}}}`, false},
+    {"missing attribute", `{"name": "example", "rulesets": {"main": {"target": "branch"}}}`, false},
+    {"list item enum", `{"name": "example", "rulesets": {"main": {"target": "tag", "bypass_actors": [{"bypass_mode": "never"}], "rules": {}}}}`, false},
+    {"type", `{"name": "example", "rulesets": {"main": {"target": "tag", "rules": {"deletion": "yes"}}}}`, false},
+  } {
+    t.Run(tc.name, func(t *testing.T) {
+      var vars map[string]json.RawMessage
+      require.NoError(t, json.Unmarshal([]byte(tc.vars), &vars))
+
+      err := s.Validate(vars)
+      if tc.valid {
+        assert.NoError(t, err)
+      } else {
+        assert.Error(t, err)
+      }
+    })
+  }
+}
@@ -0,0 +1,82 @@
+variable "name" {
+  description = "Name of the repository"
+  type        = string
+
+  validation {
+    condition     = can(regex("^[a-z-]+$", var.name))
+    error_message = "Name must be lowercase"
+  }
+}
+
+variable "visibility" {
+  type     = string
+  default  = "public"
+  nullable = false
+
+  validation {
+    condition     = contains(["public", "private"], var.visibility)
+    error_message = "Visibility must be public or private"
+  }
+}
+
+variable "topics" {
+  type    = set(string)
+  default = []
+}
+
+variable "scanning" {
+  type = object({
+    state     = optional(string, "configured")
+    languages = optional(list(string), null)
+  })
+  default = null
+
+  validation {
+    condition     = var.scanning == null || contains(["configured", "not-configured"], try(var.scanning.state, ""))
+    error_message = "Scanning state must be configured or not-configured"
+  }
+}
+
+variable "rulesets" {
+  type = map(object({
+    target = string
+    bypass_actors = optional(list(object({
+      bypass_mode = string
+      actor_id    = optional(string, null)
+    })), [])
+    rules = object({
+      deletion = optional(bool, false)
+      pattern = optional(object({
+        operator = string
+        negate   = optional(bool, false)
+      }), null)
+    })
+  }))
+  default  = {}
+  nullable = false
+
+  validation {
+    condition     = alltrue([for k, v in var.rulesets : can(regex("^[a-z_]+$", k))])
+    error_message = "Ruleset keys must be lowercase"
+  }
+
+  validation {
+    condition     = alltrue([for k, v in var.rulesets : contains(["branch", "tag"], v.target)])
+    error_message = "Ruleset target must be branch or tag"
+  }
+
+  validation {
+    condition     = alltrue([for k, v in var.rulesets : alltrue([for b in v.bypass_actors : contains(["always", "pull_request"], b.bypass_mode)])])
+    error_message = "Bypass mode must be always or pull_request"
+  }
+
+  validation {
+    condition     = alltrue([for k, v in var.rulesets : try(contains(["starts_with", "regex"], v.rules.pattern.operator), true)])
+    error_message = "Pattern operator must be starts_with or regex"
+  }
+
+  validation {
+    condition     = alltrue([for k, v in var.rulesets : length(v.bypass_actors) <= 2])
+    error_message = "Not converted"
+  }
+}
@@ -0,0 +1,37 @@
+package schema
+
+import (
+  "bytes"
+  "encoding/json"
+
+  "github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+// Validate checks module inputs, as decoded by tfvars.Decode, against the schema.
+func (s *Schema) Validate(vars map[string]json.RawMessage) error {
+  src, err := json.Marshal(s)
+  if err != nil {
+    return err
+  }
+
+  compiler := jsonschema.NewCompiler()
+  if err := compiler.AddResource("inputs.schema.json", bytes.NewReader(src)); err != nil {
+    return err
+  }
+  compiled, err := compiler.Compile("inputs.schema.json")
+  if err != nil {
+    return err
+  }
+
+  doc, err := json.Marshal(vars)
+  if err != nil {
+    return err
+  }
+  decoder := json.NewDecoder(bytes.NewReader(doc))
+  decoder.UseNumber()
+  var v interface{}
+  if err := decoder.Decode(&v); err != nil {
+    return err
+  }
+  return compiled.Validate(v)
+}
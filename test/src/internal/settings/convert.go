@@ -0,0 +1,480 @@
+package settings
+
+import (
+  "encoding/json"
+  "fmt"
+  "sort"
+  "strings"
+
+  "github.com/cloudposse/terraform-example-module/internal/rulesets"
+  "github.com/cloudposse/terraform-example-module/internal/tfvars"
+)
+
+// Repository settings mapped to the variable of the same meaning
+var repositoryVariables = map[string]string{
+  "name":                            "name",
+  "description":                     "description",
+  "homepage":                        "homepage_url",
+  "visibility":                      "visibility",
+  "has_issues":                      "has_issues",
+  "has_projects":                    "has_projects",
+  "has_wiki":                        "has_wiki",
+  "has_downloads":                   "has_downloads",
+  "has_discussions":                 "has_discussions",
+  "is_template":                     "is_template",
+  "default_branch":                  "default_branch",
+  "allow_squash_merge":              "allow_squash_merge",
+  "allow_merge_commit":              "allow_merge_commit",
+  "allow_rebase_merge":              "allow_rebase_merge",
+  "allow_auto_merge":                "allow_auto_merge",
+  "allow_update_branch":             "allow_update_branch",
+  "delete_branch_on_merge":          "delete_branch_on_merge",
+  "squash_merge_commit_title":       "squash_merge_commit_title",
+  "squash_merge_commit_message":     "squash_merge_commit_message",
+  "merge_commit_title":              "merge_commit_title",
+  "merge_commit_message":            "merge_commit_message",
+  "web_commit_signoff_required":     "web_commit_signoff_required",
+  "archived":                        "archived",
+  "auto_init":                       "auto_init",
+  "gitignore_template":              "gitignore_template",
+  "license_template":                "license_template",
+  "enable_vulnerability_alerts":     "enable_vulnerability_alerts",
+  "enable_automated_security_fixes": "enable_dependabot_security_updates",
+}
+
+// Security and analysis features supported by the security_and_analysis variable
+var securityFeatures = []string{
+  "advanced_security",
+  "code_security",
+  "secret_scanning",
+  "secret_scanning_push_protection",
+  "secret_scanning_ai_detection",
+  "secret_scanning_non_provider_patterns",
+  "secret_scanning_validity_checks",
+}
+
+// Convert converts the settings to the inputs of the module. Only inputs present in the settings are returned.
+// Anything that can not be expressed by the module is left out and reported in the returned warnings.
+func Convert(s *Settings, names *Names, actors *rulesets.Actors) (map[string]interface{}, []string) {
+  c := &converter{
+    vars:   make(map[string]interface{}),
+    names:  names,
+    actors: actors,
+  }
+
+  if s.Extends != "" {
+    c.warn("_extends: settings inherited from %q are not included, convert them separately", s.Extends)
+  }
+  for _, section := range sortedKeys(s.Other) {
+    c.warn("%s: not supported, skipping", section)
+  }
+
+  c.repository(s.Repository)
+  c.labels(s.Labels)
+  c.collaborators(s.Collaborators, s.Teams)
+  c.rulesets(s.Branches, s.Rulesets)
+  c.environments(s.Environments)
+  c.autolinks(s.Autolinks)
+  c.customProperties(s.CustomProperties)
+
+  for _, milestone := range s.Milestones {
+    c.warn("milestones: milestone %q is not supported, skipping", milestone.Title)
+  }
+
+  return c.vars, c.warnings
+}
+
+type converter struct {
+  vars     map[string]interface{}
+  warnings []string
+  names    *Names
+  actors   *rulesets.Actors
+}
+
+func (c *converter) warn(format string, args ...interface{}) {
+  c.warnings = append(c.warnings, fmt.Sprintf(format, args...))
+}
+
+func (c *converter) repository(repository map[string]interface{}) {
+  for _, key := range sortedKeys(repository) {
+    value := repository[key]
+    if variable, ok := repositoryVariables[key]; ok {
+      c.vars[variable] = value
+      continue
+    }
+
+    switch key {
+    case "private":
+      if _, ok := repository["visibility"]; ok {
+        continue
+      }
+      if private, _ := value.(bool); private {
+        c.vars["visibility"] = "private"
+      } else {
+        c.vars["visibility"] = "public"
+      }
+    case "topics":
+      switch topics := value.(type) {
+      case string:
+        c.vars["topics"] = splitList(topics)
+      case []interface{}:
+        c.vars["topics"] = topics
+      default:
+        c.warn("repository.topics: unexpected value %v, skipping", value)
+      }
+    case "security_and_analysis":
+      c.securityAndAnalysis(value)
+    default:
+      c.warn("repository.%s: not supported, skipping", key)
+    }
+  }
+}
+
+func (c *converter) securityAndAnalysis(value interface{}) {
+  settings, ok := value.(map[string]interface{})
+  if !ok {
+    c.warn("repository.security_and_analysis: unexpected value %v, skipping", value)
+    return
+  }
+
+  out := make(map[string]interface{})
+  for _, feature := range sortedKeys(settings) {
+    supported := false
+    for _, f := range securityFeatures {
+      supported = supported || f == feature
+    }
+    setting, _ := settings[feature].(map[string]interface{})
+    status, _ := setting["status"].(string)
+    if !supported || (status != "enabled" && status != "disabled") {
+      c.warn("repository.security_and_analysis.%s: not supported, skipping", feature)
+      continue
+    }
+    out[feature] = status == "enabled"
+  }
+  if len(out) > 0 {
+    c.vars["security_and_analysis"] = out
+  }
+}
+
+func (c *converter) labels(labels []Label) {
+  if len(labels) == 0 {
+    return
+  }
+
+  out := make(map[string]interface{}, len(labels))
+  for _, label := range labels {
+    name := label.Name
+    if label.NewName != "" || label.OldName != "" {
+      c.warn("labels: renaming label %q is not supported, the label is recreated", label.Name)
+      if label.NewName != "" {
+        name = label.NewName
+      }
+    }
+    out[name] = map[string]interface{}{
+      "color":       strings.TrimPrefix(label.Color, "#"),
+      "description": label.Description,
+    }
+  }
+  c.vars["labels"] = out
+}
+
+func (c *converter) collaborators(collaborators []Collaborator, teams []Team) {
+  users := make(map[string]interface{})
+  for _, collaborator := range collaborators {
+    if len(collaborator.Include) > 0 || len(collaborator.Exclude) > 0 {
+      c.warn("collaborators: include and exclude of %q are not supported, the collaborator is added", collaborator.Username)
+    }
+    users[collaborator.Username] = collaborator.Permission
+  }
+  if len(users) > 0 {
+    c.vars["users"] = users
+  }
+
+  out := make(map[string]interface{})
+  for _, team := range teams {
+    if len(team.Include) > 0 || len(team.Exclude) > 0 {
+      c.warn("teams: include and exclude of %q are not supported, the team is added", team.Name)
+    }
+    out[team.Name] = team.Permission
+  }
+  if len(out) > 0 {
+    c.vars["teams"] = out
+  }
+}
+
+func (c *converter) rulesets(branches []Branch, exported []map[string]interface{}) {
+  out := make(map[string]rulesets.Ruleset)
+  exists := func(k string) bool {
+    _, ok := out[k]
+    return ok
+  }
+
+  // Classic branch protections are converted to rulesets with the same effect
+  for _, branch := range branches {
+    if branch.Protection == nil {
+      c.warn("branches: removing the protection of %q is not supported, skipping", branch.Name)
+      continue
+    }
+    out[tfvars.Key(branch.Name, "branch", exists)] = c.branchRuleset(branch)
+  }
+
+  if len(exported) > 0 {
+    src, err := json.Marshal(exported)
+    if err != nil {
+      c.warn("rulesets: %s, skipping", err)
+    } else if exports, err := rulesets.ParseExports(src); err != nil {
+      c.warn("rulesets: %s, skipping", err)
+    } else {
+      converted, warnings := rulesets.ToModule(exports, c.actors)
+      for _, warning := range warnings {
+        c.warn("rulesets: %s", warning)
+      }
+      for _, key := range sortedKeys(converted) {
+        out[tfvars.Key(key, "ruleset", exists)] = converted[key]
+      }
+    }
+  }
+
+  if len(out) > 0 {
+    c.vars["rulesets"] = out
+  }
+}
+
+func (c *converter) branchRuleset(branch Branch) rulesets.Ruleset {
+  protection := branch.Protection
+  warn := func(format string, args ...interface{}) {
+    c.warn("branches: %q: "+format, append([]interface{}{branch.Name}, args...)...)
+  }
+
+  ruleset := rulesets.Ruleset{
+    Name:        fmt.Sprintf("%s branch protection", branch.Name),
+    Enforcement: "active",
+    Target:      "branch",
+    Conditions: rulesets.Conditions{
+      RefName: rulesets.RefName{
+        Include: []string{branch.Name},
+        Exclude: []string{},
+      },
+    },
+    Rules: rulesets.Rules{
+      // Force pushes and deletions are blocked by branch protection unless allowed
+      NonFastForward: protection.AllowForcePushes == nil || !*protection.AllowForcePushes,
+      Deletion:       protection.AllowDeletions == nil || !*protection.AllowDeletions,
+    },
+  }
+
+  // Branch protection applies to admins only when enforced
+  if protection.EnforceAdmins == nil || !*protection.EnforceAdmins {
+    ruleset.BypassActors = []rulesets.BypassActor{
+      {BypassMode: "always", ActorID: "admin", ActorType: "RepositoryRole"},
+    }
+  }
+
+  if reviews := protection.RequiredPullRequestReviews; reviews != nil {
+    ruleset.Rules.PullRequest = &rulesets.PullRequest{
+      DismissStaleReviewsOnPush:    reviews.DismissStaleReviews,
+      RequireCodeOwnerReview:       reviews.RequireCodeOwnerReviews,
+      RequireLastPushApproval:      reviews.RequireLastPushApproval,
+      RequiredApprovingReviewCount: reviews.RequiredApprovingReviewCount,
+    }
+    for _, key := range sortedKeys(reviews.Other) {
+      warn("required_pull_request_reviews.%s is not supported, skipping", key)
+    }
+  }
+
+  if protection.RequiredConversationResolution != nil && *protection.RequiredConversationResolution {
+    if ruleset.Rules.PullRequest == nil {
+      ruleset.Rules.PullRequest = &rulesets.PullRequest{}
+    }
+    ruleset.Rules.PullRequest.RequiredReviewThreadResolution = true
+  }
+
+  if checks := protection.RequiredStatusChecks; checks != nil {
+    ruleset.Rules.RequiredStatusChecks = &rulesets.RequiredStatusChecks{
+      RequiredCheck:                    []rulesets.StatusCheck{},
+      StrictRequiredStatusChecksPolicy: checks.Strict,
+    }
+    for _, context := range checks.Contexts {
+      ruleset.Rules.RequiredStatusChecks.RequiredCheck = append(ruleset.Rules.RequiredStatusChecks.RequiredCheck, rulesets.StatusCheck{Context: context})
+    }
+    for _, check := range checks.Checks {
+      statusCheck := rulesets.StatusCheck{Context: check.Context}
+      if check.AppID != nil && *check.AppID != -1 {
+        statusCheck.IntegrationID, _ = c.actors.Name("Integration", *check.AppID)
+      }
+      ruleset.Rules.RequiredStatusChecks.RequiredCheck = append(ruleset.Rules.RequiredStatusChecks.RequiredCheck, statusCheck)
+    }
+  }
+
+  for _, key := range sortedKeys(protection.Other) {
+    if protection.Other[key] == nil || protection.Other[key] == false {
+      continue
+    }
+    warn("%s is not supported, skipping", key)
+  }
+
+  return ruleset
+}
+
+func (c *converter) environments(environments []Environment) {
+  if len(environments) == 0 {
+    return
+  }
+
+  out := make(map[string]interface{}, len(environments))
+  for _, environment := range environments {
+    warn := func(format string, args ...interface{}) {
+      c.warn("environments: %q: "+format, append([]interface{}{environment.Name}, args...)...)
+    }
+
+    env := map[string]interface{}{
+      "wait_timer":          environment.WaitTimer,
+      "prevent_self_review": environment.PreventSelfReview,
+    }
+
+    if len(environment.Reviewers) > 0 {
+      teams := []string{}
+      users := []string{}
+      for _, reviewer := range environment.Reviewers {
+        switch reviewer.Type {
+        case "Team":
+          if slug, ok := c.names.Teams[reviewer.ID]; ok {
+            teams = append(teams, slug)
+          } else {
+            warn("reviewer team %d is unknown, skipping", reviewer.ID)
+          }
+        case "User":
+          if login, ok := c.names.Users[reviewer.ID]; ok {
+            users = append(users, login)
+          } else {
+            warn("reviewer user %d is unknown, skipping", reviewer.ID)
+          }
+        default:
+          warn("reviewer type %q is not supported, skipping", reviewer.Type)
+        }
+      }
+      if len(teams) > 0 || len(users) > 0 {
+        env["reviewers"] = map[string]interface{}{
+          "teams": teams,
+          "users": users,
+        }
+      }
+    }
+
+    if policy := environment.DeploymentBranchPolicy; policy != nil {
+      branchPolicy := map[string]interface{}{
+        "protected_branches": policy.ProtectedBranches,
+      }
+      switch policies := policy.CustomBranchPolicies.(type) {
+      case []interface{}:
+        branches := []string{}
+        tags := []string{}
+        for _, p := range policies {
+          switch p := p.(type) {
+          case string:
+            branches = append(branches, p)
+          case map[string]interface{}:
+            name, _ := p["name"].(string)
+            if p["type"] == "tag" {
+              tags = append(tags, name)
+            } else {
+              branches = append(branches, name)
+            }
+          }
+        }
+        branchPolicy["custom_branches"] = map[string]interface{}{
+          "branches": branches,
+          "tags":     tags,
+        }
+      case bool:
+        if policies {
+          warn("custom branch policies are not listed, no branch can deploy")
+          branchPolicy["custom_branches"] = map[string]interface{}{
+            "branches": []string{},
+          }
+        }
+      }
+      env["deployment_branch_policy"] = branchPolicy
+    }
+
+    if len(environment.Variables) > 0 {
+      variables := make(map[string]interface{}, len(environment.Variables))
+      for _, variable := range environment.Variables {
+        variables[variable.Name] = variable.Value
+      }
+      env["variables"] = variables
+    }
+
+    for _, key := range sortedKeys(environment.Other) {
+      warn("%s is not supported, skipping", key)
+    }
+
+    out[environment.Name] = env
+  }
+  c.vars["environments"] = out
+}
+
+func (c *converter) autolinks(autolinks []Autolink) {
+  if len(autolinks) == 0 {
+    return
+  }
+
+  out := make(map[string]interface{}, len(autolinks))
+  for _, autolink := range autolinks {
+    reference := map[string]interface{}{
+      "key_prefix":          autolink.KeyPrefix,
+      "target_url_template": autolink.URLTemplate,
+    }
+    if autolink.IsAlphanumeric != nil {
+      reference["is_alphanumeric"] = *autolink.IsAlphanumeric
+    }
+    out[tfvars.Key(autolink.KeyPrefix, "autolink", func(k string) bool {
+      _, ok := out[k]
+      return ok
+    })] = reference
+  }
+  c.vars["autolink_references"] = out
+}
+
+func (c *converter) customProperties(properties []CustomProperty) {
+  if len(properties) == 0 {
+    return
+  }
+
+  // The settings do not carry the property types; lists are multi select and other values are strings
+  out := make(map[string]interface{}, len(properties))
+  for _, property := range properties {
+    switch value := property.Value.(type) {
+    case []interface{}:
+      out[property.Name] = map[string]interface{}{"multi_select": value}
+    case bool:
+      out[property.Name] = map[string]interface{}{"boolean": value}
+    case nil:
+      c.warn("custom_properties: removing property %q is not supported, skipping", property.Name)
+    default:
+      out[property.Name] = map[string]interface{}{"string": fmt.Sprint(value)}
+    }
+  }
+  if len(out) > 0 {
+    c.vars["custom_properties"] = out
+  }
+}
+
+func splitList(s string) []string {
+  out := []string{}
+  for _, item := range strings.Split(s, ",") {
+    if item = strings.TrimSpace(item); item != "" {
+      out = append(out, item)
+    }
+  }
+  return out
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+  keys := make([]string, 0, len(m))
+  for key := range m {
+    keys = append(keys, key)
+  }
+  sort.Strings(keys)
+  return keys
+}
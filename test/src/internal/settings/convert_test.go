@@ -0,0 +1,116 @@
+package settings
+
+import (
+  "os"
+  "testing"
+
+  "github.com/cloudposse/terraform-example-module/internal/rulesets"
+  "github.com/stretchr/testify/assert"
+  "github.com/stretchr/testify/require"
+)
+
+// Test the conversion of a probot settings file to the module inputs.
+func TestConvert(t *testing.T) {
+  src, err := os.ReadFile("testdata/settings.yml")
+  require.NoError(t, err)
+
+  s, err := Parse(src)
+  require.NoError(t, err)
+
+  names := NewNames()
+  names.Teams[1234] = "core"
+
+  vars, warnings := Convert(s, names, rulesets.NewActors())
+
+  assert.Equal(t, "example", vars["name"])
+  assert.Equal(t, "https://example.com", vars["homepage_url"])
+  assert.Equal(t, "private", vars["visibility"])
+  assert.Equal(t, []string{"terraform", "github"}, vars["topics"])
+  assert.Equal(t, false, vars["has_wiki"])
+  assert.Equal(t, true, vars["enable_dependabot_security_updates"])
+  assert.Equal(t, map[string]interface{}{
+    "secret_scanning":                 true,
+    "secret_scanning_push_protection": false,
+  }, vars["security_and_analysis"])
+
+  labels := vars["labels"].(map[string]interface{})
+  assert.Equal(t, 3, len(labels))
+  assert.Equal(t, "d73a4a", labels["bug"].(map[string]interface{})["color"])
+  assert.Equal(t, "000000", labels["feature"].(map[string]interface{})["color"])
+
+  assert.Equal(t, map[string]interface{}{"octocat": "push"}, vars["users"])
+  assert.Equal(t, map[string]interface{}{"core": "admin", "docs": "triage"}, vars["teams"])
+
+  converted := vars["rulesets"].(map[string]rulesets.Ruleset)
+  assert.Equal(t, 3, len(converted))
+
+  main := converted["main"]
+  assert.Equal(t, []string{"main"}, main.Conditions.RefName.Include)
+  // Admins are enforced, so nobody bypasses the ruleset
+  assert.Empty(t, main.BypassActors)
+  assert.True(t, main.Rules.Deletion)
+  assert.True(t, main.Rules.NonFastForward)
+  assert.Equal(t, 1, main.Rules.PullRequest.RequiredApprovingReviewCount)
+  assert.True(t, main.Rules.PullRequest.DismissStaleReviewsOnPush)
+  assert.True(t, main.Rules.PullRequest.RequireCodeOwnerReview)
+  assert.Equal(t, []rulesets.StatusCheck{{Context: "test"}}, main.Rules.RequiredStatusChecks.RequiredCheck)
+  assert.True(t, main.Rules.RequiredStatusChecks.StrictRequiredStatusChecksPolicy)
+
+  release := converted["release"]
+  assert.Equal(t, []rulesets.BypassActor{{BypassMode: "always", ActorID: "admin", ActorType: "RepositoryRole"}}, release.BypassActors)
+  assert.False(t, release.Rules.Deletion)
+  assert.True(t, release.Rules.PullRequest.RequiredReviewThreadResolution)
+
+  assert.Equal(t, []string{"v*"}, converted["tags"].Conditions.RefName.Include)
+  assert.True(t, converted["tags"].Rules.Deletion)
+
+  production := vars["environments"].(map[string]interface{})["production"].(map[string]interface{})
+  assert.Equal(t, 5, production["wait_timer"])
+  assert.Equal(t, map[string]interface{}{"teams": []string{"core"}, "users": []string{}}, production["reviewers"])
+  assert.Equal(t, map[string]interface{}{
+    "protected_branches": false,
+    "custom_branches": map[string]interface{}{
+      "branches": []string{"main"},
+      "tags":     []string{"v*"},
+    },
+  }, production["deployment_branch_policy"])
+  assert.Equal(t, map[string]interface{}{"REGION": "us-east-2"}, production["variables"])
+
+  assert.Equal(t, map[string]interface{}{
+    "jira": map[string]interface{}{
+      "key_prefix":          "JIRA-",
+      "target_url_template": "https://jira.example.com/browse/JIRA-<num>",
+    },
+  }, vars["autolink_references"])
+
+  assert.Equal(t, map[string]interface{}{
+    "team":      map[string]interface{}{"string": "platform"},
+    "languages": map[string]interface{}{"multi_select": []interface{}{"go", "hcl"}},
+  }, vars["custom_properties"])
+
+  assert.Equal(t, []string{
+    `_extends: settings inherited from ".github" are not included, convert them separately`,
+    `repository.use_squash_pr_title_as_default: not supported, skipping`,
+    `labels: renaming label "feature" is not supported, the label is recreated`,
+    `branches: "main": required_pull_request_reviews.dismissal_restrictions is not supported, skipping`,
+    `branches: "main": required_linear_history is not supported, skipping`,
+    `branches: removing the protection of "legacy" is not supported, skipping`,
+    `rulesets: ruleset "Tags": rule "required_signatures" is not supported, skipping`,
+    `environments: "production": reviewer user 5678 is unknown, skipping`,
+    `milestones: milestone "v1.0" is not supported, skipping`,
+  }, warnings)
+}
+
+// Test that the settings of this repository convert without losing anything but the inherited settings.
+func TestConvertRepositorySettings(t *testing.T) {
+  src, err := os.ReadFile("../../../../.github/settings.yml")
+  require.NoError(t, err)
+
+  s, err := Parse(src)
+  require.NoError(t, err)
+
+  vars, warnings := Convert(s, NewNames(), rulesets.NewActors())
+  assert.Equal(t, "terraform-github-repository", vars["name"])
+  assert.Equal(t, 6, len(vars["topics"].([]interface{})))
+  assert.Equal(t, []string{`_extends: settings inherited from ".github" are not included, convert them separately`}, warnings)
+}
@@ -0,0 +1,56 @@
+package settings
+
+import (
+  "context"
+
+  "github.com/google/go-github/v73/github"
+)
+
+// Names maps the team and user IDs of environment reviewers to the team slugs and logins accepted by the module.
+type Names struct {
+  Teams map[int64]string
+  Users map[int64]string
+}
+
+// NewNames returns an empty Names.
+func NewNames() *Names {
+  return &Names{
+    Teams: make(map[int64]string),
+    Users: make(map[int64]string),
+  }
+}
+
+// Load registers the teams of the organization and the users reviewing the environments of the settings.
+func (n *Names) Load(ctx context.Context, client *github.Client, owner string, s *Settings) error {
+  opts := &github.ListOptions{PerPage: 100}
+  for {
+    teams, resp, err := client.Teams.ListTeams(ctx, owner, opts)
+    if err != nil {
+      return err
+    }
+    for _, team := range teams {
+      n.Teams[team.GetID()] = team.GetSlug()
+    }
+    if resp.NextPage == 0 {
+      break
+    }
+    opts.Page = resp.NextPage
+  }
+
+  for _, environment := range s.Environments {
+    for _, reviewer := range environment.Reviewers {
+      if reviewer.Type != "User" {
+        continue
+      }
+      if _, ok := n.Users[reviewer.ID]; ok {
+        continue
+      }
+      user, _, err := client.Users.GetByID(ctx, reviewer.ID)
+      if err != nil {
+        return err
+      }
+      n.Users[reviewer.ID] = user.GetLogin()
+    }
+  }
+  return nil
+}
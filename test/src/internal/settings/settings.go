@@ -0,0 +1,136 @@
+// Package settings converts repository settings in the format of the probot settings app (.github/settings.yml)
+// to the inputs of the module.
+package settings
+
+import (
+  "gopkg.in/yaml.v3"
+)
+
+// Settings is the content of a .github/settings.yml file.
+type Settings struct {
+  Extends          string                 `yaml:"_extends"`
+  Repository       map[string]interface{} `yaml:"repository"`
+  Labels           []Label                `yaml:"labels"`
+  Milestones       []Milestone            `yaml:"milestones"`
+  Collaborators    []Collaborator         `yaml:"collaborators"`
+  Teams            []Team                 `yaml:"teams"`
+  Branches         []Branch               `yaml:"branches"`
+  Environments     []Environment          `yaml:"environments"`
+  Autolinks        []Autolink             `yaml:"autolinks"`
+  CustomProperties []CustomProperty       `yaml:"custom_properties"`
+  // GitHub API format, converted with the rulesets package
+  Rulesets []map[string]interface{} `yaml:"rulesets"`
+
+  // Sections not known to the converter
+  Other map[string]interface{} `yaml:",inline"`
+}
+
+type Label struct {
+  Name        string `yaml:"name"`
+  NewName     string `yaml:"new_name"`
+  OldName     string `yaml:"oldname"`
+  Color       string `yaml:"color"`
+  Description string `yaml:"description"`
+}
+
+type Milestone struct {
+  Title       string `yaml:"title"`
+  Description string `yaml:"description"`
+  State       string `yaml:"state"`
+}
+
+type Collaborator struct {
+  Username   string   `yaml:"username"`
+  Permission string   `yaml:"permission"`
+  Exclude    []string `yaml:"exclude"`
+  Include    []string `yaml:"include"`
+}
+
+type Team struct {
+  Name       string   `yaml:"name"`
+  Permission string   `yaml:"permission"`
+  Exclude    []string `yaml:"exclude"`
+  Include    []string `yaml:"include"`
+}
+
+type Branch struct {
+  Name string `yaml:"name"`
+  // Null removes the branch protection
+  Protection *Protection `yaml:"protection"`
+}
+
+type Protection struct {
+  RequiredPullRequestReviews     *PullRequestReviews   `yaml:"required_pull_request_reviews"`
+  RequiredStatusChecks           *StatusChecks         `yaml:"required_status_checks"`
+  EnforceAdmins                  *bool                 `yaml:"enforce_admins"`
+  AllowForcePushes               *bool                 `yaml:"allow_force_pushes"`
+  AllowDeletions                 *bool                 `yaml:"allow_deletions"`
+  RequiredConversationResolution *bool                 `yaml:"required_conversation_resolution"`
+  Other                          map[string]interface{} `yaml:",inline"`
+}
+
+type PullRequestReviews struct {
+  RequiredApprovingReviewCount int                    `yaml:"required_approving_review_count"`
+  DismissStaleReviews          bool                   `yaml:"dismiss_stale_reviews"`
+  RequireCodeOwnerReviews      bool                   `yaml:"require_code_owner_reviews"`
+  RequireLastPushApproval      bool                   `yaml:"require_last_push_approval"`
+  Other                        map[string]interface{} `yaml:",inline"`
+}
+
+type StatusChecks struct {
+  Strict   bool          `yaml:"strict"`
+  Contexts []string      `yaml:"contexts"`
+  Checks   []StatusCheck `yaml:"checks"`
+}
+
+type StatusCheck struct {
+  Context string `yaml:"context"`
+  AppID   *int64 `yaml:"app_id"`
+}
+
+type Environment struct {
+  Name                   string                  `yaml:"name"`
+  WaitTimer              int                     `yaml:"wait_timer"`
+  PreventSelfReview      bool                    `yaml:"prevent_self_review"`
+  Reviewers              []Reviewer              `yaml:"reviewers"`
+  DeploymentBranchPolicy *DeploymentBranchPolicy `yaml:"deployment_branch_policy"`
+  Variables              []Variable              `yaml:"variables"`
+  Other                  map[string]interface{}  `yaml:",inline"`
+}
+
+type Reviewer struct {
+  // Team or User
+  Type string `yaml:"type"`
+  ID   int64  `yaml:"id"`
+}
+
+type DeploymentBranchPolicy struct {
+  ProtectedBranches bool `yaml:"protected_branches"`
+  // Either a boolean or a list of branch names or {name, type} policies
+  CustomBranchPolicies interface{} `yaml:"custom_branch_policies"`
+}
+
+type Variable struct {
+  Name  string `yaml:"name"`
+  Value string `yaml:"value"`
+}
+
+type Autolink struct {
+  KeyPrefix      string `yaml:"key_prefix"`
+  URLTemplate    string `yaml:"url_template"`
+  IsAlphanumeric *bool  `yaml:"is_alphanumeric"`
+}
+
+type CustomProperty struct {
+  Name  string      `yaml:"name"`
+  Value interface{} `yaml:"value"`
+}
+
+// Parse reads a settings.yml file.
+func Parse(src []byte) (*Settings, error) {
+  var s Settings
+  if err := yaml.Unmarshal(src, &s); err != nil {
+    return nil, err
+  }
+  return &s, nil
+}
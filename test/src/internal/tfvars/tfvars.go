@@ -0,0 +1,126 @@
+// Package tfvars reads and writes Terraform variable definition files for the module inputs.
+package tfvars
+
+import (
+  "bytes"
+  "encoding/json"
+  "fmt"
+  "regexp"
+  "sort"
+  "strings"
+
+  "github.com/hashicorp/hcl/v2/hclparse"
+  "github.com/hashicorp/hcl/v2/hclwrite"
+  "github.com/zclconf/go-cty/cty"
+  ctyjson "github.com/zclconf/go-cty/cty/json"
+)
+
+const (
+  FormatHCL  = "hcl"
+  FormatJSON = "json"
+)
+
+var invalidKeyChars = regexp.MustCompile(`[^a-zA-Z0-9_]+`)
+
+// Encode renders the variables as a .tfvars file in the given format.
+// Values are anything encoding/json can marshal, and variables are written in alphabetical order.
+func Encode(vars map[string]interface{}, format string) ([]byte, error) {
+  switch format {
+  case FormatJSON:
+    var out bytes.Buffer
+    enc := json.NewEncoder(&out)
+    enc.SetEscapeHTML(false)
+    enc.SetIndent("", "  ")
+    if err := enc.Encode(vars); err != nil {
+      return nil, err
+    }
+    return out.Bytes(), nil
+  case FormatHCL:
+    names := make([]string, 0, len(vars))
+    for name := range vars {
+      names = append(names, name)
+    }
+    sort.Strings(names)
+
+    file := hclwrite.NewEmptyFile()
+    body := file.Body()
+    for i, name := range names {
+      val, err := toCty(vars[name])
+      if err != nil {
+        return nil, fmt.Errorf("variable %s: %w", name, err)
+      }
+      if i > 0 {
+        body.AppendNewline()
+      }
+      body.SetAttributeValue(name, val)
+    }
+    return hclwrite.Format(file.Bytes()), nil
+  default:
+    return nil, fmt.Errorf("unsupported format %q, must be %s or %s", format, FormatHCL, FormatJSON)
+  }
+}
+
+// Decode reads a .tfvars or .tfvars.json file and returns every variable as JSON.
+// Only literal values are supported, as the file is evaluated without any variables or functions.
+func Decode(filename string, src []byte) (map[string]json.RawMessage, error) {
+  parser := hclparse.NewParser()
+
+  parse := parser.ParseHCL
+  if strings.HasSuffix(filename, ".json") {
+    parse = parser.ParseJSON
+  }
+
+  file, diags := parse(src, filename)
+  if diags.HasErrors() {
+    return nil, diags
+  }
+
+  attrs, diags := file.Body.JustAttributes()
+  if diags.HasErrors() {
+    return nil, diags
+  }
+
+  vars := make(map[string]json.RawMessage, len(attrs))
+  for name, attr := range attrs {
+    val, diags := attr.Expr.Value(nil)
+    if diags.HasErrors() {
+      return nil, diags
+    }
+    out, err := ctyjson.Marshal(val, val.Type())
+    if err != nil {
+      return nil, fmt.Errorf("variable %s: %w", name, err)
+    }
+    vars[name] = out
+  }
+  return vars, nil
+}
+
+// Key returns a map key accepted by the module inputs for name, alphanumeric and underscores only and not starting
+// with a number. Names that are empty or start with a number are prefixed with kind, and a numeric suffix is added
+// when exists reports the key as already used.
+func Key(name, kind string, exists func(string) bool) string {
+  key := strings.Trim(invalidKeyChars.ReplaceAllString(strings.ToLower(name), "_"), "_")
+  if key == "" {
+    key = kind
+  } else if key[0] >= '0' && key[0] <= '9' {
+    key = kind + "_" + key
+  }
+
+  unique := key
+  for i := 2; exists(unique); i++ {
+    unique = fmt.Sprintf("%s_%d", key, i)
+  }
+  return unique
+}
+
+func toCty(v interface{}) (cty.Value, error) {
+  src, err := json.Marshal(v)
+  if err != nil {
+    return cty.NilVal, err
+  }
+  ty, err := ctyjson.ImpliedType(src)
+  if err != nil {
+    return cty.NilVal, err
+  }
+  return ctyjson.Unmarshal(src, ty)
+}
@@ -0,0 +1,92 @@
+// Package vcr records the interactions of the GitHub provider and the go-github client with the GitHub API through
+// a local proxy, and replays them offline.
+//
+// Both clients reach the proxy as a GitHub Enterprise Server: the provider with GITHUB_BASE_URL set to the URL of
+// the proxy, and go-github with WithEnterpriseURLs. The proxy forwards /api/v3/ to the REST API and /api/graphql to
+// the GraphQL API. Recorded interactions are scrubbed of tokens, private keys and secrets before they are written.
+package vcr
+
+import (
+  "crypto/sha256"
+  "encoding/json"
+  "fmt"
+  "os"
+  "path/filepath"
+)
+
+// Mode is how the proxy handles requests.
+type Mode string
+
+const (
+  // ModeLive runs against the live API without the proxy.
+  ModeLive Mode = ""
+  // ModeRecord forwards requests to the live API and records the interactions.
+  ModeRecord Mode = "record"
+  // ModeReplay answers requests with recorded interactions, without network access.
+  ModeReplay Mode = "replay"
+)
+
+// ParseMode parses a mode, as set in the GITHUB_VCR environment variable.
+func ParseMode(s string) (Mode, error) {
+  switch mode := Mode(s); mode {
+  case ModeLive, ModeRecord, ModeReplay:
+    return mode, nil
+  }
+  return "", fmt.Errorf("unsupported mode %q, must be %s or %s", s, ModeRecord, ModeReplay)
+}
+
+// Cassette is a list of recorded interactions.
+type Cassette struct {
+  Interactions []Interaction `json:"interactions"`
+}
+
+// Interaction is a request and the response of the API.
+type Interaction struct {
+  Request  Request  `json:"request"`
+  Response Response `json:"response"`
+}
+
+// Request is a recorded request, with the path and query of the URL relative to the API.
+type Request struct {
+  Method string `json:"method"`
+  URL    string `json:"url"`
+  Body   string `json:"body,omitempty"`
+}
+
+// Response is a recorded response, with the headers clients rely on.
+type Response struct {
+  Status int               `json:"status"`
+  Header map[string]string `json:"header,omitempty"`
+  Body   string            `json:"body,omitempty"`
+}
+
+// Load reads a cassette.
+func Load(filename string) (*Cassette, error) {
+  src, err := os.ReadFile(filename)
+  if err != nil {
+    return nil, err
+  }
+  var c Cassette
+  if err := json.Unmarshal(src, &c); err != nil {
+    return nil, fmt.Errorf("%s: %w", filename, err)
+  }
+  return &c, nil
+}
+
+// Save writes a cassette, creating its folder.
+func (c *Cassette) Save(filename string) error {
+  src, err := json.MarshalIndent(c, "", "  ")
+  if err != nil {
+    return err
+  }
+  if err := os.MkdirAll(filepath.Dir(filename), 0o755); err != nil {
+    return err
+  }
+  return os.WriteFile(filename, append(src, '\n'), 0o644)
+}
+
+// Seed returns a random looking ID derived from name, to replace the random IDs of a recording so that replays use
+// the same names.
+func Seed(name string) string {
+  return fmt.Sprintf("%x", sha256.Sum256([]byte(name)))[:6]
+}
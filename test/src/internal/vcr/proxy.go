@@ -0,0 +1,229 @@
+package vcr
+
+import (
+  "bytes"
+  "errors"
+  "fmt"
+  "io"
+  "net/http"
+  "net/http/httptest"
+  "net/url"
+  "strings"
+  "sync"
+)
+
+// Response headers recorded and replayed, the others vary between runs
+var recordedHeaders = []string{"Content-Type", "Link", "Location", "Retry-After"}
+
+// Request headers not forwarded to the API
+var hopHeaders = []string{"Accept-Encoding", "Connection", "Content-Length", "Host", "Proxy-Connection", "Te", "Trailer", "Transfer-Encoding", "Upgrade"}
+
+// Proxy is a local GitHub Enterprise Server style endpoint which records or replays the interactions with the API.
+type Proxy struct {
+  mode     Mode
+  upstream *url.URL
+  scrubber *Scrubber
+  client   *http.Client
+  server   *httptest.Server
+
+  mu       sync.Mutex
+  cassette *Cassette
+  used     []bool
+  errs     []error
+}
+
+// NewProxy starts a proxy in record or replay mode. The API is reached at upstream, usually https://api.github.com/
+// or https://github.example.com/api/v3/ for GitHub Enterprise Server, when recording, and the interactions are replayed from cassette when replaying.
+func NewProxy(mode Mode, cassette *Cassette, upstream string, scrubber *Scrubber) (*Proxy, error) {
+  u, err := url.Parse(strings.TrimSuffix(upstream, "/") + "/")
+  if err != nil {
+    return nil, err
+  }
+
+  p := &Proxy{
+    mode:     mode,
+    upstream: u,
+    scrubber: scrubber,
+    client: &http.Client{
+      // Redirects are followed by the clients, through the proxy
+      CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+    },
+  }
+  switch mode {
+  case ModeRecord:
+    p.cassette = &Cassette{}
+  case ModeReplay:
+    if cassette == nil {
+      return nil, errors.New("replay requires a cassette")
+    }
+    p.cassette = cassette
+    p.used = make([]bool, len(cassette.Interactions))
+  default:
+    return nil, fmt.Errorf("unsupported mode %q, must be %s or %s", mode, ModeRecord, ModeReplay)
+  }
+
+  p.server = httptest.NewServer(http.HandlerFunc(p.serveHTTP))
+  return p, nil
+}
+
+// URL returns the base URL of the proxy, to set as GITHUB_BASE_URL for the provider, and as enterprise URLs of
+// go-github.
+func (p *Proxy) URL() string {
+  return p.server.URL + "/"
+}
+
+// Cassette returns the interactions recorded so far, or the replayed cassette.
+func (p *Proxy) Cassette() *Cassette {
+  p.mu.Lock()
+  defer p.mu.Unlock()
+  return p.cassette
+}
+
+// Close stops the proxy, returning the requests which could not be forwarded or replayed.
+func (p *Proxy) Close() error {
+  p.server.Close()
+  p.mu.Lock()
+  defer p.mu.Unlock()
+  return errors.Join(p.errs...)
+}
+
+func (p *Proxy) fail(w http.ResponseWriter, status int, err error) {
+  p.mu.Lock()
+  p.errs = append(p.errs, err)
+  p.mu.Unlock()
+  http.Error(w, "vcr: "+err.Error(), status)
+}
+
+func (p *Proxy) serveHTTP(w http.ResponseWriter, r *http.Request) {
+  path, ok := apiPath(r.URL.Path)
+  if !ok {
+    http.Error(w, fmt.Sprintf("vcr: %s is not a path of the API", r.URL.Path), http.StatusNotFound)
+    return
+  }
+  if r.URL.RawQuery != "" {
+    path += "?" + r.URL.RawQuery
+  }
+
+  body, err := io.ReadAll(r.Body)
+  if err != nil {
+    p.fail(w, http.StatusBadRequest, err)
+    return
+  }
+
+  var response Response
+  if p.mode == ModeRecord {
+    response, err = p.forward(r, path, body)
+    if err != nil {
+      p.fail(w, http.StatusBadGateway, fmt.Errorf("%s %s: %w", r.Method, path, err))
+      return
+    }
+  } else {
+    response, ok = p.replay(r.Method, path, body)
+    if !ok {
+      p.fail(w, http.StatusNotImplemented, fmt.Errorf("%s %s: no recorded interaction", r.Method, p.scrubber.Scrub(path)))
+      return
+    }
+  }
+
+  for name, value := range response.Header {
+    if name == "Link" || name == "Location" {
+      // Follow up requests go through the proxy
+      value = strings.ReplaceAll(value, p.upstream.String(), p.URL()+"api/v3/")
+    }
+    w.Header().Set(name, value)
+  }
+  w.WriteHeader(response.Status)
+  io.WriteString(w, response.Body)
+}
+
+// apiPath maps a path of the proxy to a path of the API, relative to its base URL.
+func apiPath(path string) (string, bool) {
+  switch {
+  case path == "/api/graphql":
+    return "graphql", true
+  case strings.HasPrefix(path, "/api/v3/"):
+    return strings.TrimPrefix(path, "/api/v3/"), true
+  }
+  return "", false
+}
+
+// upstreamURL returns the URL of path on the API. GitHub Enterprise Server serves GraphQL at /api/graphql, next to
+// the REST API at /api/v3/.
+func (p *Proxy) upstreamURL(path string) string {
+  base := p.upstream.String()
+  if path == "graphql" && strings.HasSuffix(base, "/api/v3/") {
+    return strings.TrimSuffix(base, "v3/") + path
+  }
+  return base + path
+}
+
+// forward sends the request to the API and records the interaction, returning the response as received.
+func (p *Proxy) forward(r *http.Request, path string, body []byte) (Response, error) {
+  req, err := http.NewRequestWithContext(r.Context(), r.Method, p.upstreamURL(path), bytes.NewReader(body))
+  if err != nil {
+    return Response{}, err
+  }
+  req.Header = r.Header.Clone()
+  for _, name := range hopHeaders {
+    req.Header.Del(name)
+  }
+
+  resp, err := p.client.Do(req)
+  if err != nil {
+    return Response{}, err
+  }
+  defer resp.Body.Close()
+  respBody, err := io.ReadAll(resp.Body)
+  if err != nil {
+    return Response{}, err
+  }
+
+  response := Response{Status: resp.StatusCode, Header: make(map[string]string), Body: string(respBody)}
+  recorded := Response{Status: resp.StatusCode, Header: make(map[string]string), Body: p.scrubber.Scrub(string(respBody))}
+  for _, name := range recordedHeaders {
+    if value := resp.Header.Get(name); value != "" {
+      response.Header[name] = value
+      recorded.Header[name] = p.scrubber.Scrub(value)
+    }
+  }
+
+  p.mu.Lock()
+  defer p.mu.Unlock()
+  p.cassette.Interactions = append(p.cassette.Interactions, Interaction{
+    Request: Request{
+      Method: r.Method,
+      URL:    p.scrubber.Scrub(path),
+      Body:   p.scrubber.Scrub(string(body)),
+    },
+    Response: recorded,
+  })
+  return response, nil
+}
+
+// replay returns the response of the first unused interaction with the same method and URL, preferring one with the
+// same body, as bodies may hold values generated by each run such as keys.
+func (p *Proxy) replay(method, path string, body []byte) (Response, bool) {
+  path = p.scrubber.Scrub(path)
+  scrubbed := p.scrubber.Scrub(string(body))
+
+  p.mu.Lock()
+  defer p.mu.Unlock()
+  match := -1
+  for i, interaction := range p.cassette.Interactions {
+    if p.used[i] || interaction.Request.Method != method || interaction.Request.URL != path {
+      continue
+    }
+    if interaction.Request.Body == scrubbed {
+      match = i
+      break
+    }
+    if match < 0 {
+      match = i
+    }
+  }
+  if match < 0 {
+    return Response{}, false
+  }
+  p.used[match] = true
+  return p.cassette.Interactions[match].Response, true
+}
@@ -0,0 +1,36 @@
+package vcr
+
+import (
+  "regexp"
+  "strings"
+)
+
+// Redacted replaces the scrubbed values.
+const Redacted = "REDACTED"
+
+var (
+  // Personal access, OAuth, user to server, server to server and refresh tokens
+  tokenPattern = regexp.MustCompile(`\b(gh[opsur]_[A-Za-z0-9]{36,251}|github_pat_[A-Za-z0-9_]{22,244})\b`)
+  // PEM private keys, as is or escaped in JSON
+  privateKeyPattern = regexp.MustCompile(`(?s)-----BEGIN [A-Z ]*PRIVATE KEY-----.*?-----END [A-Z ]*PRIVATE KEY-----`)
+  // Secret values of JSON payloads, such as actions secrets and webhook secrets
+  secretPattern = regexp.MustCompile(`"(encrypted_value|secret|token|password|client_secret)"(\s*:\s*)"[^"]*"`)
+)
+
+// Scrubber removes tokens and secrets from recorded text, and replaces values such as random IDs.
+type Scrubber struct {
+  replacer *strings.Replacer
+}
+
+// NewScrubber returns a scrubber replacing each old value with the new value that follows it.
+func NewScrubber(oldnew ...string) *Scrubber {
+  return &Scrubber{replacer: strings.NewReplacer(oldnew...)}
+}
+
+// Scrub returns v without tokens and secrets, and with the replacements of the scrubber.
+func (s *Scrubber) Scrub(v string) string {
+  v = tokenPattern.ReplaceAllString(v, Redacted)
+  v = privateKeyPattern.ReplaceAllString(v, Redacted)
+  v = secretPattern.ReplaceAllString(v, `"$1"$2"`+Redacted+`"`)
+  return s.replacer.Replace(v)
+}
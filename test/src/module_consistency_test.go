@@ -0,0 +1,467 @@
+package test
+
+import (
+  "fmt"
+  "os"
+  "path/filepath"
+  "sort"
+  "strings"
+  "testing"
+
+  "github.com/hashicorp/hcl/v2"
+  "github.com/hashicorp/hcl/v2/hclparse"
+  "github.com/hashicorp/hcl/v2/hclsyntax"
+  "github.com/stretchr/testify/assert"
+  "github.com/stretchr/testify/require"
+  "github.com/zclconf/go-cty/cty"
+  ctyjson "github.com/zclconf/go-cty/cty/json"
+)
+
+// Modules of this repository, relative to the test folder
+var consistencyModules = []string{"../../", "../../modules/code-scanning", "../../modules/environment-custom-protection-rules", "../../modules/organization-ruleset", "../../modules/repositories"}
+
+// Test that every variable of the module is passed by the complete example.
+func TestConsistencyCompleteExampleVariables(t *testing.T) {
+  t.Parallel()
+
+  root, err := loadModule("../../")
+  require.NoError(t, err)
+  example, err := loadModule("../../examples/complete")
+  require.NoError(t, err)
+
+  call, ok := example.moduleCalls["example"]
+  require.True(t, ok, "examples/complete must call the module as module.example")
+
+  for _, name := range sortedNames(root.variables) {
+    if _, ok := call.Body.Attributes[name]; !ok {
+      t.Errorf("%s: variable %s is not passed to the module by examples/complete", root.variables[name].DeclRange, name)
+    }
+  }
+}
+
+// Test that the variables of the examples have the type of the module variable they are passed to.
+func TestConsistencyExampleVariableTypes(t *testing.T) {
+  t.Parallel()
+
+  examples, err := filepath.Glob("../../examples/*")
+  require.NoError(t, err)
+
+  for _, dir := range examples {
+    example, err := loadModule(dir)
+    require.NoError(t, err)
+
+    for _, call := range example.moduleCalls {
+      source, diags := call.Body.Attributes["source"].Expr.Value(nil)
+      require.False(t, diags.HasErrors(), diags.Error())
+      // Only modules of this repository
+      if !strings.HasPrefix(source.AsString(), "../") {
+        continue
+      }
+
+      module, err := loadModule(filepath.Join(dir, source.AsString()))
+      require.NoError(t, err)
+
+      for _, name := range sortedNames(call.Body.Attributes) {
+        // Only variables passed as is
+        traversal, ok := call.Body.Attributes[name].Expr.(*hclsyntax.ScopeTraversalExpr)
+        if !ok || len(traversal.Traversal) != 2 || traversal.Traversal.RootName() != "var" {
+          continue
+        }
+        exampleVariable, ok := example.variables[traversal.Traversal[1].(hcl.TraverseAttr).Name]
+        if !ok {
+          continue
+        }
+        moduleVariable, ok := module.variables[name]
+        if !ok {
+          t.Errorf("%s: variable %s is not declared by %s", call.Body.Attributes[name].SrcRange, name, source.AsString())
+          continue
+        }
+
+        exampleType := example.typeOf(exampleVariable)
+        // Examples may accept anything and let the module validate it
+        if exampleType == "" || exampleType == "any" {
+          continue
+        }
+        if moduleType := module.typeOf(moduleVariable); exampleType != moduleType {
+          t.Errorf("%s: type of variable %s differs from %s", exampleVariable.DeclRange, exampleVariable.Name, moduleVariable.DeclRange)
+        }
+      }
+    }
+  }
+}
+
+// Attributes of the module variables deliberately not used by the module, by path
+var unusedAttributes = map[string]string{
+  // Deprecated in favor of pull_request, and ignored by the module
+  "rulesets.rules.required_pull_request_reviews": "../../",
+}
+
+// Test that every attribute declared in the object types of the module variables is used by the module.
+func TestConsistencyVariableAttributesUsed(t *testing.T) {
+  t.Parallel()
+
+  for _, dir := range consistencyModules {
+    module, err := loadModule(dir)
+    require.NoError(t, err)
+
+    used, whole := module.usedNames()
+    for _, name := range sortedNames(module.variables) {
+      variable := module.variables[name]
+      if whole[name] {
+        continue
+      }
+      attr, ok := variable.block.Body.Attributes["type"]
+      if !ok {
+        continue
+      }
+      for _, a := range objectAttributes(attr.Expr, name) {
+        if unusedAttribute(dir, a.path) {
+          continue
+        }
+        if !used[a.name] {
+          t.Errorf("%s: attribute %s of variable %s is not used by the module", a.rng, a.path, name)
+        }
+      }
+    }
+  }
+}
+
+// Test that every output of the module is exposed by the complete example, with the same sensitivity.
+func TestConsistencyCompleteExampleOutputs(t *testing.T) {
+  t.Parallel()
+
+  root, err := loadModule("../../")
+  require.NoError(t, err)
+  example, err := loadModule("../../examples/complete")
+  require.NoError(t, err)
+
+  for _, name := range sortedNames(root.outputs) {
+    output := root.outputs[name]
+    exampleOutput, ok := example.outputs[name]
+    if !ok {
+      t.Errorf("%s: output %s is not exposed by examples/complete", output.DeclRange, name)
+      continue
+    }
+    if output.Sensitive != exampleOutput.Sensitive {
+      t.Errorf("%s: sensitivity of output %s differs from %s", exampleOutput.DeclRange, name, output.DeclRange)
+    }
+  }
+
+  for _, name := range sortedNames(example.outputs) {
+    if _, ok := root.outputs[name]; !ok {
+      t.Errorf("%s: output %s is not an output of the module", example.outputs[name].DeclRange, name)
+    }
+  }
+}
+
+// Test that README.yaml lists every example.
+func TestConsistencyReadmeExamples(t *testing.T) {
+  t.Parallel()
+
+  readme, err := os.ReadFile("../../README.yaml")
+  require.NoError(t, err)
+
+  examples, err := filepath.Glob("../../examples/*")
+  require.NoError(t, err)
+
+  for _, dir := range examples {
+    name := "examples/" + filepath.Base(dir)
+    if !strings.Contains(string(readme), "[`"+name+"`]") {
+      t.Errorf("../../README.yaml: example %s is not listed", name)
+    }
+  }
+}
+
+// Test that modules/repositories knows every attribute it passes to the root module, and passes every variable of it.
+func TestConsistencyRepositoriesAttributes(t *testing.T) {
+  t.Parallel()
+
+  root, err := loadModule("../../")
+  require.NoError(t, err)
+  repositories, err := loadModule("../../modules/repositories")
+  require.NoError(t, err)
+
+  call, ok := repositories.moduleCalls["repository"]
+  require.True(t, ok, "modules/repositories must call the root module as module.repository")
+
+  var known []string
+  for _, file := range repositories.files {
+    for _, block := range file.Body.(*hclsyntax.Body).Blocks {
+      attr, ok := block.Body.Attributes["repository_attributes"]
+      if block.Type != "locals" || !ok {
+        continue
+      }
+      val, diags := attr.Expr.Value(nil)
+      require.False(t, diags.HasErrors(), diags.Error())
+      for _, v := range val.AsValueSlice() {
+        known = append(known, v.AsString())
+      }
+    }
+  }
+  require.NotEmpty(t, known, "modules/repositories must declare local.repository_attributes")
+
+  var passed []string
+  for _, name := range sortedNames(call.Body.Attributes) {
+    if name != "source" && name != "for_each" {
+      passed = append(passed, name)
+    }
+  }
+  sort.Strings(known)
+  require.Equal(t, passed, known)
+  require.Equal(t, sortedNames(root.variables), passed)
+}
+
+// Defaults of modules/repositories which differ from the root module on purpose
+var repositoriesDefaultExceptions = map[string]string{
+  // Null in context.tf, where it means enabled
+  "enabled": "true",
+}
+
+// Test that modules/repositories falls back to the defaults of the root module for the attributes a repository
+// definition leaves out.
+func TestConsistencyRepositoriesDefaults(t *testing.T) {
+  t.Parallel()
+
+  root, err := loadModule("../../")
+  require.NoError(t, err)
+  repositories, err := loadModule("../../modules/repositories")
+  require.NoError(t, err)
+
+  call, ok := repositories.moduleCalls["repository"]
+  require.True(t, ok, "modules/repositories must call the root module as module.repository")
+
+  for _, name := range sortedNames(call.Body.Attributes) {
+    variable, ok := root.variables[name]
+    if !ok {
+      continue
+    }
+    // Attributes are passed as try(each.value.<name>, <default>)
+    fn, ok := call.Body.Attributes[name].Expr.(*hclsyntax.FunctionCallExpr)
+    if !ok || fn.Name != "try" || len(fn.Args) != 2 {
+      if _, optional := variable.block.Body.Attributes["default"]; optional {
+        t.Errorf("%s: %s must be passed as try(each.value.%s, <default>)", call.Body.Attributes[name].SrcRange, name, name)
+      }
+      continue
+    }
+    fallback, diags := fn.Args[1].Value(nil)
+    require.False(t, diags.HasErrors(), diags.Error())
+
+    want := cty.NullVal(cty.DynamicPseudoType)
+    if attr, ok := variable.block.Body.Attributes["default"]; ok {
+      want, diags = attr.Expr.Value(nil)
+      require.False(t, diags.HasErrors(), diags.Error())
+    }
+    if exception, ok := repositoriesDefaultExceptions[name]; ok {
+      assert.Equal(t, exception, ctyString(fallback), "default of %s", name)
+      continue
+    }
+    assert.Equal(t, ctyString(want), ctyString(fallback), "%s: default of %s differs from the root module", fn.Args[1].Range(), name)
+  }
+}
+
+// ctyString returns v as JSON, null for null values of any type.
+func ctyString(v cty.Value) string {
+  if v.IsNull() {
+    return "null"
+  }
+  src, err := ctyjson.Marshal(v, v.Type())
+  if err != nil {
+    return v.GoString()
+  }
+  return string(src)
+}
+
+type consistencyVariable struct {
+  Name      string
+  DeclRange hcl.Range
+  block     *hclsyntax.Block
+}
+
+type consistencyOutput struct {
+  Name      string
+  DeclRange hcl.Range
+  Sensitive bool
+}
+
+type consistencyModule struct {
+  files       map[string]*hcl.File
+  variables   map[string]*consistencyVariable
+  outputs     map[string]*consistencyOutput
+  moduleCalls map[string]*hclsyntax.Block
+}
+
+// loadModule parses the .tf files of a module folder.
+func loadModule(dir string) (*consistencyModule, error) {
+  files, err := filepath.Glob(filepath.Join(dir, "*.tf"))
+  if err != nil {
+    return nil, err
+  }
+
+  m := &consistencyModule{
+    files:       make(map[string]*hcl.File),
+    variables:   make(map[string]*consistencyVariable),
+    outputs:     make(map[string]*consistencyOutput),
+    moduleCalls: make(map[string]*hclsyntax.Block),
+  }
+
+  parser := hclparse.NewParser()
+  for _, filename := range files {
+    file, diags := parser.ParseHCLFile(filename)
+    if diags.HasErrors() {
+      return nil, diags
+    }
+    m.files[filename] = file
+
+    for _, block := range file.Body.(*hclsyntax.Body).Blocks {
+      switch block.Type {
+      case "variable":
+        m.variables[block.Labels[0]] = &consistencyVariable{
+          Name:      block.Labels[0],
+          DeclRange: block.DefRange(),
+          block:     block,
+        }
+      case "output":
+        output := &consistencyOutput{Name: block.Labels[0], DeclRange: block.DefRange()}
+        if attr, ok := block.Body.Attributes["sensitive"]; ok {
+          val, diags := attr.Expr.Value(nil)
+          if diags.HasErrors() {
+            return nil, diags
+          }
+          output.Sensitive = val.True()
+        }
+        m.outputs[block.Labels[0]] = output
+      case "module":
+        m.moduleCalls[block.Labels[0]] = block
+      }
+    }
+  }
+  return m, nil
+}
+
+// typeOf returns the type constraint of the variable as source tokens, without whitespace and comments.
+func (m *consistencyModule) typeOf(v *consistencyVariable) string {
+  attr, ok := v.block.Body.Attributes["type"]
+  if !ok {
+    return ""
+  }
+  rng := attr.Expr.Range()
+  src := m.files[rng.Filename].Bytes[rng.Start.Byte:rng.End.Byte]
+
+  tokens, _ := hclsyntax.LexExpression(src, rng.Filename, rng.Start)
+  var out strings.Builder
+  for _, token := range tokens {
+    switch token.Type {
+    case hclsyntax.TokenComment, hclsyntax.TokenNewline, hclsyntax.TokenEOF:
+      continue
+    }
+    out.Write(token.Bytes)
+  }
+  // Trailing commas are optional
+  return strings.NewReplacer(",)", ")", ",}", "}").Replace(out.String())
+}
+
+// usedNames returns the attribute names and string keys referenced outside of variable declarations, and the
+// variables whose attributes are all used, as they are iterated over or encoded.
+func (m *consistencyModule) usedNames() (map[string]bool, map[string]bool) {
+  used := make(map[string]bool)
+  whole := make(map[string]bool)
+  addWhole := func(expr hclsyntax.Expression) {
+    if e, ok := expr.(*hclsyntax.ScopeTraversalExpr); ok && len(e.Traversal) == 2 && e.Traversal.RootName() == "var" {
+      whole[e.Traversal[1].(hcl.TraverseAttr).Name] = true
+    }
+  }
+  addTraversal := func(traversal hcl.Traversal) {
+    for _, step := range traversal {
+      switch s := step.(type) {
+      case hcl.TraverseAttr:
+        used[s.Name] = true
+      case hcl.TraverseIndex:
+        if s.Key.Type() == cty.String {
+          used[s.Key.AsString()] = true
+        }
+      }
+    }
+  }
+
+  for _, file := range m.files {
+    for _, block := range file.Body.(*hclsyntax.Body).Blocks {
+      if block.Type == "variable" {
+        continue
+      }
+      hclsyntax.VisitAll(block, func(node hclsyntax.Node) hcl.Diagnostics {
+        switch n := node.(type) {
+        case *hclsyntax.ScopeTraversalExpr:
+          addTraversal(n.Traversal)
+        case *hclsyntax.RelativeTraversalExpr:
+          addTraversal(n.Traversal)
+        case *hclsyntax.ForExpr:
+          addWhole(n.CollExpr)
+        case *hclsyntax.FunctionCallExpr:
+          if n.Name == "jsonencode" || n.Name == "yamlencode" {
+            for _, arg := range n.Args {
+              addWhole(arg)
+            }
+          }
+        case *hclsyntax.LiteralValueExpr:
+          if n.Val.Type() == cty.String && n.Val.IsKnown() && !n.Val.IsNull() {
+            used[n.Val.AsString()] = true
+          }
+        }
+        return nil
+      })
+    }
+  }
+  return used, whole
+}
+
+// unusedAttribute returns whether the attribute at path, or one of its parents, is deliberately not used by the module
+// of dir.
+func unusedAttribute(dir, path string) bool {
+  for unused, unusedDir := range unusedAttributes {
+    if unusedDir == dir && (path == unused || strings.HasPrefix(path, unused+".")) {
+      return true
+    }
+  }
+  return false
+}
+
+type objectAttribute struct {
+  name string
+  path string
+  rng  hcl.Range
+}
+
+// objectAttributes returns the attributes of the object types in a type constraint.
+func objectAttributes(expr hclsyntax.Expression, path string) []objectAttribute {
+  call, ok := expr.(*hclsyntax.FunctionCallExpr)
+  if !ok || len(call.Args) == 0 {
+    return nil
+  }
+
+  switch call.Name {
+  case "list", "set", "map", "optional":
+    return objectAttributes(call.Args[0], path)
+  case "object":
+    object, ok := call.Args[0].(*hclsyntax.ObjectConsExpr)
+    if !ok {
+      return nil
+    }
+    var attrs []objectAttribute
+    for _, item := range object.Items {
+      name := hcl.ExprAsKeyword(item.KeyExpr)
+      attrs = append(attrs, objectAttribute{name: name, path: fmt.Sprintf("%s.%s", path, name), rng: item.KeyExpr.Range()})
+      attrs = append(attrs, objectAttributes(item.ValueExpr, fmt.Sprintf("%s.%s", path, name))...)
+    }
+    return attrs
+  }
+  return nil
+}
+
+func sortedNames[V any](m map[string]V) []string {
+  names := make([]string, 0, len(m))
+  for name := range m {
+    names = append(names, name)
+  }
+  sort.Strings(names)
+  return names
+}
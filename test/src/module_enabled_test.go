@@ -0,0 +1,280 @@
+package test
+
+import (
+  "fmt"
+  "os"
+  "path/filepath"
+  "sort"
+  "testing"
+
+  "github.com/hashicorp/hcl/v2"
+  "github.com/hashicorp/hcl/v2/hclparse"
+  "github.com/hashicorp/hcl/v2/hclsyntax"
+  "github.com/stretchr/testify/assert"
+  "github.com/stretchr/testify/require"
+  "github.com/zclconf/go-cty/cty"
+)
+
+// Blocks deliberately not gated on var.enabled
+var enabledExceptions = map[string]string{
+  // Only read when the module is disabled, to refuse destroying a protected repository
+  "data.github_repository.deletion_protection": "../../main.tf",
+}
+
+// Functions returning an empty collection when all their arguments are empty
+var enabledCollectionFunctions = map[string]bool{
+  "compact":      true,
+  "concat":       true,
+  "distinct":     true,
+  "flatten":      true,
+  "keys":         true,
+  "merge":        true,
+  "nonsensitive": true,
+  "sensitive":    true,
+  "setunion":     true,
+  "toset":        true,
+  "tomap":        true,
+  "tolist":       true,
+  "values":       true,
+}
+
+// Test that the count or for_each of every resource and data block of the modules is gated on var.enabled,
+// directly or through locals derived from it, so that nothing is created when the module is disabled.
+func TestModulesEnabled(t *testing.T) {
+  t.Parallel()
+
+  for _, dir := range []string{"../../", "../../modules/code-scanning", "../../modules/environment-custom-protection-rules", "../../modules/organization-ruleset"} {
+    files, err := filepath.Glob(filepath.Join(dir, "*.tf"))
+    require.NoError(t, err)
+
+    offenders, err := ungatedBlocks(files)
+    require.NoError(t, err)
+    for _, offender := range offenders {
+      t.Error(offender)
+    }
+  }
+}
+
+// Test that blocks bypassing var.enabled are reported.
+func TestModulesEnabledOffenders(t *testing.T) {
+  t.Parallel()
+
+  src := `
+locals {
+  users = var.enabled ? var.users : {}
+  teams = var.teams
+}
+
+resource "github_repository" "default" {
+  count = var.enabled ? 1 : 0
+}
+
+resource "github_repository_collaborators" "default" {
+  count = var.enabled && length(var.users) > 0 || length(var.teams) > 0 ? 1 : 0
+}
+
+resource "github_team_repository" "default" {
+  for_each = local.teams
+}
+
+resource "github_repository_collaborator" "default" {
+  for_each = { for k, v in local.users : k => v }
+}
+
+data "github_team" "default" {
+  for_each = toset(concat(keys(local.users), keys(local.teams)))
+}
+
+resource "github_issue_label" "default" {
+}
+`
+  file := filepath.Join(t.TempDir(), "main.tf")
+  require.NoError(t, os.WriteFile(file, []byte(src), 0o644))
+
+  offenders, err := ungatedBlocks([]string{file})
+  require.NoError(t, err)
+  assert.Equal(t, []string{
+    fmt.Sprintf("%s:12: resource github_repository_collaborators.default: count is not gated on var.enabled", file),
+    fmt.Sprintf("%s:16: resource github_team_repository.default: for_each is not gated on var.enabled", file),
+    fmt.Sprintf("%s:24: data github_team.default: for_each is not gated on var.enabled", file),
+    fmt.Sprintf("%s:27: resource github_issue_label.default: neither count nor for_each is set", file),
+  }, offenders)
+}
+
+// ungatedBlocks returns the resource and data blocks of a module which may be created when var.enabled is false,
+// as file:line messages.
+func ungatedBlocks(files []string) ([]string, error) {
+  parser := hclparse.NewParser()
+
+  type block struct {
+    kind string
+    name string
+    body *hclsyntax.Body
+    file string
+    line int
+  }
+  var blocks []block
+  locals := make(map[string]hclsyntax.Expression)
+
+  for _, filename := range files {
+    file, diags := parser.ParseHCLFile(filename)
+    if diags.HasErrors() {
+      return nil, diags
+    }
+    for _, b := range file.Body.(*hclsyntax.Body).Blocks {
+      switch b.Type {
+      case "locals":
+        for name, attr := range b.Body.Attributes {
+          locals[name] = attr.Expr
+        }
+      case "resource", "data":
+        blocks = append(blocks, block{
+          kind: b.Type,
+          name: b.Labels[0] + "." + b.Labels[1],
+          body: b.Body,
+          file: filename,
+          line: b.DefRange().Start.Line,
+        })
+      }
+    }
+  }
+
+  g := &enabledGate{locals: locals, gated: make(map[string]bool)}
+
+  var offenders []string
+  for _, b := range blocks {
+    address := b.name
+    if b.kind == "data" {
+      address = "data." + address
+    }
+    if file, ok := enabledExceptions[address]; ok && filepath.Clean(file) == filepath.Clean(b.file) {
+      continue
+    }
+
+    attr, ok := b.body.Attributes["count"]
+    if !ok {
+      attr, ok = b.body.Attributes["for_each"]
+    }
+    switch {
+    case !ok:
+      offenders = append(offenders, fmt.Sprintf("%s:%d: %s %s: neither count nor for_each is set", b.file, b.line, b.kind, b.name))
+    case !g.isEmpty(attr.Expr):
+      offenders = append(offenders, fmt.Sprintf("%s:%d: %s %s: %s is not gated on var.enabled", b.file, attr.SrcRange.Start.Line, b.kind, b.name, attr.Name))
+    }
+  }
+
+  sort.Strings(offenders)
+  return offenders, nil
+}
+
+// enabledGate decides whether expressions are guaranteed to be zero, null or empty when var.enabled is false.
+type enabledGate struct {
+  locals map[string]hclsyntax.Expression
+  gated  map[string]bool
+}
+
+// isEmpty reports whether expr is zero, null or empty when var.enabled is false.
+func (g *enabledGate) isEmpty(expr hclsyntax.Expression) bool {
+  switch e := expr.(type) {
+  case *hclsyntax.ParenthesesExpr:
+    return g.isEmpty(e.Expression)
+  case *hclsyntax.LiteralValueExpr:
+    return e.Val.IsNull() || e.Val.RawEquals(cty.Zero)
+  case *hclsyntax.TupleConsExpr:
+    for _, item := range e.Exprs {
+      if !g.isEmpty(item) {
+        return false
+      }
+    }
+    return true
+  case *hclsyntax.ObjectConsExpr:
+    return len(e.Items) == 0
+  case *hclsyntax.ScopeTraversalExpr:
+    return g.isEmptyLocal(e.Traversal)
+  case *hclsyntax.ForExpr:
+    return g.isEmpty(e.CollExpr) || (e.CondExpr != nil && g.requiresEnabled(e.CondExpr))
+  case *hclsyntax.ConditionalExpr:
+    if g.requiresEnabled(e.Condition) {
+      return g.isEmpty(e.FalseResult)
+    }
+    return g.isEmpty(e.TrueResult) && g.isEmpty(e.FalseResult)
+  case *hclsyntax.FunctionCallExpr:
+    if !enabledCollectionFunctions[e.Name] {
+      return false
+    }
+    for _, arg := range e.Args {
+      if !g.isEmpty(arg) {
+        return false
+      }
+    }
+    return true
+  }
+  return false
+}
+
+// requiresEnabled reports whether the condition is false when var.enabled is false.
+func (g *enabledGate) requiresEnabled(expr hclsyntax.Expression) bool {
+  switch e := expr.(type) {
+  case *hclsyntax.ParenthesesExpr:
+    return g.requiresEnabled(e.Expression)
+  case *hclsyntax.ScopeTraversalExpr:
+    if isTraversal(e.Traversal, "var", "enabled") {
+      return true
+    }
+    if name, ok := localName(e.Traversal); ok {
+      if local, ok := g.locals[name]; ok {
+        return g.requiresEnabled(local)
+      }
+    }
+  case *hclsyntax.BinaryOpExpr:
+    switch e.Op {
+    case hclsyntax.OpLogicalAnd:
+      return g.requiresEnabled(e.LHS) || g.requiresEnabled(e.RHS)
+    case hclsyntax.OpLogicalOr:
+      return g.requiresEnabled(e.LHS) && g.requiresEnabled(e.RHS)
+    case hclsyntax.OpGreaterThan, hclsyntax.OpNotEqual:
+      // length(x) > 0 and length(x) != 0 with x empty
+      call, ok := e.LHS.(*hclsyntax.FunctionCallExpr)
+      literal, isLiteral := e.RHS.(*hclsyntax.LiteralValueExpr)
+      return ok && isLiteral && call.Name == "length" && len(call.Args) == 1 && literal.Val.RawEquals(cty.Zero) && g.isEmpty(call.Args[0])
+    }
+  }
+  return false
+}
+
+func (g *enabledGate) isEmptyLocal(traversal hcl.Traversal) bool {
+  name, ok := localName(traversal)
+  if !ok {
+    return false
+  }
+  if gated, ok := g.gated[name]; ok {
+    return gated
+  }
+  local, ok := g.locals[name]
+  if !ok {
+    return false
+  }
+  // Cycles are not gated
+  g.gated[name] = false
+  g.gated[name] = g.isEmpty(local)
+  return g.gated[name]
+}
+
+func localName(traversal hcl.Traversal) (string, bool) {
+  if len(traversal) < 2 || traversal.RootName() != "local" {
+    return "", false
+  }
+  attr, ok := traversal[1].(hcl.TraverseAttr)
+  if !ok {
+    return "", false
+  }
+  return attr.Name, true
+}
+
+func isTraversal(traversal hcl.Traversal, root, name string) bool {
+  if len(traversal) != 2 || traversal.RootName() != root {
+    return false
+  }
+  attr, ok := traversal[1].(hcl.TraverseAttr)
+  return ok && attr.Name == name
+}
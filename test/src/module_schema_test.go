@@ -0,0 +1,73 @@
+package test
+
+import (
+  "encoding/json"
+  "os"
+  "path/filepath"
+  "strings"
+  "testing"
+
+  "github.com/cloudposse/terraform-example-module/internal/schema"
+  "github.com/cloudposse/terraform-example-module/internal/tfvars"
+  "github.com/hashicorp/hcl/v2"
+  "github.com/hashicorp/hcl/v2/hclsyntax"
+  "github.com/stretchr/testify/require"
+)
+
+// Test that the fixtures of every example validate against the schema of the example inputs, and that the
+// inputs the example passes as is to the module validate against the schema of the module inputs.
+func TestSchemaExampleFixtures(t *testing.T) {
+  t.Parallel()
+
+  examples, err := filepath.Glob("../../examples/*")
+  require.NoError(t, err)
+
+  for _, dir := range examples {
+    dir := dir
+    t.Run(filepath.Base(dir), func(t *testing.T) {
+      t.Parallel()
+
+      filename := filepath.Join(dir, "fixtures.us-east-2.tfvars")
+      src, err := os.ReadFile(filename)
+      require.NoError(t, err)
+      vars, err := tfvars.Decode(filename, src)
+      require.NoError(t, err)
+
+      files, err := filepath.Glob(filepath.Join(dir, "*.tf"))
+      require.NoError(t, err)
+      s, err := schema.Generate(filepath.Base(dir), files)
+      require.NoError(t, err)
+      require.NoError(t, s.Validate(vars), filename)
+
+      example, err := loadModule(dir)
+      require.NoError(t, err)
+      for _, call := range example.moduleCalls {
+        source, diags := call.Body.Attributes["source"].Expr.Value(nil)
+        require.False(t, diags.HasErrors(), diags.Error())
+        // Only modules of this repository
+        if !strings.HasPrefix(source.AsString(), "../") {
+          continue
+        }
+
+        files, err := filepath.Glob(filepath.Join(dir, source.AsString(), "*.tf"))
+        require.NoError(t, err)
+        s, err := schema.Generate(source.AsString(), files)
+        require.NoError(t, err)
+        // The inputs computed by the example are left out, so they can't be required
+        s.Required = nil
+
+        inputs := make(map[string]json.RawMessage)
+        for name, attr := range call.Body.Attributes {
+          traversal, ok := attr.Expr.(*hclsyntax.ScopeTraversalExpr)
+          if !ok || len(traversal.Traversal) != 2 || traversal.Traversal.RootName() != "var" {
+            continue
+          }
+          if value, ok := vars[traversal.Traversal[1].(hcl.TraverseAttr).Name]; ok {
+            inputs[name] = value
+          }
+        }
+        require.NoError(t, s.Validate(inputs), "%s: inputs of module %s", filename, call.Labels[0])
+      }
+    })
+  }
+}
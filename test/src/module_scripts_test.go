@@ -0,0 +1,157 @@
+package test
+
+import (
+  "crypto"
+  "crypto/rand"
+  "crypto/rsa"
+  "crypto/sha256"
+  "crypto/x509"
+  "encoding/base64"
+  "encoding/json"
+  "encoding/pem"
+  "io"
+  "net/http"
+  "net/http/httptest"
+  "os"
+  "os/exec"
+  "strings"
+  "testing"
+
+  "github.com/stretchr/testify/assert"
+  "github.com/stretchr/testify/require"
+)
+
+// Script of the settings that the GitHub provider does not manage yet, relative to the test folder
+const githubAPIScript = "../../scripts/github-api.sh"
+
+// fakeAPI is a GitHub Enterprise Server API recording the requests to it, and responding with the code scanning
+// default setup.
+type fakeAPI struct {
+  *httptest.Server
+  requests []string
+}
+
+func newFakeAPI(t *testing.T, appKey *rsa.PublicKey) *fakeAPI {
+  f := &fakeAPI{}
+  mux := http.NewServeMux()
+  mux.HandleFunc("POST /api/v3/app/installations/42/access_tokens", func(w http.ResponseWriter, r *http.Request) {
+    parts := strings.Split(strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "), ".")
+    require.Len(t, parts, 3)
+    signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+    require.NoError(t, err)
+    digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+    claims, err := base64.RawURLEncoding.DecodeString(parts[1])
+    require.NoError(t, err)
+    var app struct {
+      Issuer string `json:"iss"`
+    }
+    require.NoError(t, json.Unmarshal(claims, &app))
+    if rsa.VerifyPKCS1v15(appKey, crypto.SHA256, digest[:], signature) != nil || app.Issuer != "1234" {
+      w.WriteHeader(http.StatusUnauthorized)
+      return
+    }
+    w.WriteHeader(http.StatusCreated)
+    io.WriteString(w, `{"token": "installation-token"}`)
+  })
+  mux.HandleFunc("/api/v3/repos/{owner}/{repo}/code-scanning/default-setup", func(w http.ResponseWriter, r *http.Request) {
+    body, err := io.ReadAll(r.Body)
+    require.NoError(t, err)
+    f.requests = append(f.requests, strings.Join([]string{r.Method, r.URL.Path, r.Header.Get("Authorization"), string(body)}, " "))
+    if r.PathValue("repo") == "missing" {
+      w.WriteHeader(http.StatusNotFound)
+      io.WriteString(w, `{"message": "Not Found"}`)
+      return
+    }
+    io.WriteString(w, `{"state": "configured", "languages": ["python", "go"], "query_suite": "default"}`)
+  })
+  f.Server = httptest.NewServer(mux)
+  t.Cleanup(f.Close)
+  return f
+}
+
+// runGitHubAPI runs the script with the environment env, without the GitHub variables of the test environment.
+func runGitHubAPI(t *testing.T, env map[string]string, stdin string, args ...string) (string, string, error) {
+  cmd := exec.Command("sh", append([]string{githubAPIScript}, args...)...)
+  for _, v := range os.Environ() {
+    if !strings.HasPrefix(v, "GITHUB_") && !strings.HasPrefix(v, "BASE_URL=") {
+      cmd.Env = append(cmd.Env, v)
+    }
+  }
+  for k, v := range env {
+    cmd.Env = append(cmd.Env, k+"="+v)
+  }
+  cmd.Stdin = strings.NewReader(stdin)
+  var stdout, stderr strings.Builder
+  cmd.Stdout, cmd.Stderr = &stdout, &stderr
+  err := cmd.Run()
+  return stdout.String(), stderr.String(), err
+}
+
+// Test that the script calls the API of the provider with its token, and fails on errors.
+func TestScriptsGitHubAPI(t *testing.T) {
+  t.Parallel()
+  for _, tool := range []string{"sh", "curl", "jq"} {
+    if _, err := exec.LookPath(tool); err != nil {
+      t.Skipf("%s is not installed", tool)
+    }
+  }
+  api := newFakeAPI(t, nil)
+
+  env := map[string]string{"GITHUB_BASE_URL": api.URL, "GITHUB_TOKEN": "personal-token"}
+  stdout, stderr, err := runGitHubAPI(t, env, `{"state":"configured"}`, "PATCH", "repos/owner/repo/code-scanning/default-setup")
+  require.NoError(t, err, stderr)
+  assert.JSONEq(t, `{"state": "configured", "languages": ["python", "go"], "query_suite": "default"}`, stdout)
+
+  stdout, stderr, err = runGitHubAPI(t, env, "", "GET", "repos/owner/repo/code-scanning/default-setup", `{languages: (.languages | sort | join(","))}`)
+  require.NoError(t, err, stderr)
+  assert.Equal(t, `{"languages":"go,python"}`+"\n", stdout)
+
+  // The base URL of the module takes precedence over the one of the provider
+  _, stderr, err = runGitHubAPI(t, map[string]string{"GITHUB_BASE_URL": "http://127.0.0.1:1", "BASE_URL": api.URL + "/api/v3/", "GITHUB_TOKEN": "personal-token"}, "", "DELETE", "repos/owner/repo/code-scanning/default-setup")
+  require.NoError(t, err, stderr)
+
+  assert.Equal(t, []string{
+    `PATCH /api/v3/repos/owner/repo/code-scanning/default-setup Bearer personal-token {"state":"configured"}`,
+    "GET /api/v3/repos/owner/repo/code-scanning/default-setup Bearer personal-token ",
+    "DELETE /api/v3/repos/owner/repo/code-scanning/default-setup Bearer personal-token ",
+  }, api.requests)
+
+  _, stderr, err = runGitHubAPI(t, env, "", "GET", "repos/owner/missing/code-scanning/default-setup")
+  assert.Error(t, err)
+  assert.Contains(t, stderr, `GET repos/owner/missing/code-scanning/default-setup: 404: {"message": "Not Found"}`)
+
+  _, stderr, err = runGitHubAPI(t, map[string]string{"GITHUB_BASE_URL": api.URL}, "", "GET", "repos/owner/repo/code-scanning/default-setup")
+  assert.Error(t, err)
+  assert.Contains(t, stderr, "GITHUB_TOKEN, or GITHUB_APP_ID, GITHUB_APP_INSTALLATION_ID and GITHUB_APP_PEM_FILE, must be set")
+}
+
+// Test that the script authenticates as the GitHub App of the provider when set.
+func TestScriptsGitHubAPIApp(t *testing.T) {
+  t.Parallel()
+  for _, tool := range []string{"sh", "curl", "jq", "openssl"} {
+    if _, err := exec.LookPath(tool); err != nil {
+      t.Skipf("%s is not installed", tool)
+    }
+  }
+  key, err := rsa.GenerateKey(rand.Reader, 2048)
+  require.NoError(t, err)
+  keyPEM := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+  api := newFakeAPI(t, &key.PublicKey)
+
+  env := map[string]string{
+    "GITHUB_BASE_URL":            api.URL,
+    "GITHUB_TOKEN":               "personal-token",
+    "GITHUB_APP_ID":              "1234",
+    "GITHUB_APP_INSTALLATION_ID": "42",
+    "GITHUB_APP_PEM_FILE":        strings.ReplaceAll(string(keyPEM), "\n", `\n`),
+  }
+  stdout, stderr, err := runGitHubAPI(t, env, "", "GET", "repos/owner/repo/code-scanning/default-setup", "{state}")
+  require.NoError(t, err, stderr)
+  assert.Equal(t, `{"state":"configured"}`+"\n", stdout)
+  assert.Equal(t, []string{"GET /api/v3/repos/owner/repo/code-scanning/default-setup Bearer installation-token "}, api.requests)
+
+  delete(env, "GITHUB_APP_INSTALLATION_ID")
+  _, stderr, err = runGitHubAPI(t, env, "", "GET", "repos/owner/repo/code-scanning/default-setup")
+  assert.Error(t, err)
+  assert.Contains(t, stderr, "must all be set")
+}
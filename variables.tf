@@ -5,7 +5,7 @@ variable "enabled" {
 }
 
 variable "name" {
-  description = "Name of the repository"
+  description = "Name of the repository. A changed name renames the repository in place and replaces the resources of the module the provider can not move to another repository"
   type        = string
 
   validation {
@@ -47,6 +47,22 @@ variable "template" {
   default = null
 }
 
+variable "recreate_on_template_change" {
+  description = "Recreate the repository when template is changed after creation. By default the plan is refused, as the template is only used when the repository is created."
+  type        = bool
+  default     = false
+  nullable    = false
+}
+
+variable "fork" {
+  description = "Create the repository as a fork of an existing repository. Can not be combined with template, auto_init, gitignore_template or license_template."
+  type = object({
+    source_owner = string
+    source_repo  = string
+  })
+  default = null
+}
+
 variable "archived" {
   description = "Whether the repository is archived"
   type        = bool
@@ -169,6 +185,19 @@ variable "default_branch" {
   default     = "main"
 }
 
+variable "manage_default_branch" {
+  description = "Manage the default branch of the repository. Defaults to true for repositories created with auto_init. Repositories created from a template or as a fork keep the default branch of their source, set it to true to switch or rename it to default_branch. Set to true for imported repositories."
+  type        = bool
+  default     = null
+}
+
+variable "rename_default_branch" {
+  description = "Rename the current default branch to default_branch instead of switching to an existing branch"
+  type        = bool
+  default     = false
+  nullable    = false
+}
+
 variable "web_commit_signoff_required" {
   description = "Require signoff on web commits"
   type        = bool
@@ -211,6 +240,12 @@ variable "enable_vulnerability_alerts" {
   default     = true
 }
 
+variable "enable_dependabot_security_updates" {
+  description = "Enable Dependabot security updates (automated security fixes). Requires vulnerability alerts to be enabled. Leave null to not manage it."
+  type        = bool
+  default     = null
+}
+
 variable "allow_update_branch" {
   description = "Allow updating the branch"
   type        = bool
@@ -218,21 +253,48 @@ variable "allow_update_branch" {
 }
 
 variable "security_and_analysis" {
-  description = "Security and analysis settings"
+  description = "Security and analysis settings. Settings left null are not managed."
   type = object({
-    advanced_security               = bool
-    secret_scanning                 = bool
-    secret_scanning_push_protection = bool
+    // Can not be changed for public repositories
+    advanced_security = optional(bool, false)
+    // Can not be changed for public repositories
+    code_security                   = optional(bool, null)
+    secret_scanning                 = optional(bool, false)
+    secret_scanning_push_protection = optional(bool, false)
+    // Require secret_scanning
+    secret_scanning_ai_detection          = optional(bool, null)
+    secret_scanning_non_provider_patterns = optional(bool, null)
+    secret_scanning_validity_checks       = optional(bool, null)
   })
   default = null
 }
 
+variable "github_base_url" {
+  description = "Base URL of the GitHub API for the fork source of existing protected forks, read with scripts/github-api.sh. Set it to the `base_url` of the provider when it is not set by the `GITHUB_BASE_URL` environment variable. Credentials are read from the environment variables of the provider, `GITHUB_TOKEN` or `GITHUB_APP_ID`, `GITHUB_APP_INSTALLATION_ID` and `GITHUB_APP_PEM_FILE`, which must be set even when the provider is configured otherwise."
+  type        = string
+  default     = null
+}
+
 variable "archive_on_destroy" {
   description = "Archive the repository on destroy"
   type        = bool
   default     = false
 }
 
+variable "deletion_protection" {
+  description = "Protect the repository from being destroyed or replaced. The plan is refused when the module is disabled, or when a template or fork change would replace the repository. Requires archive_on_destroy, so the repository is archived instead of deleted when the module is removed. Replacements requested with `terraform apply -replace` are not refused. The source of existing forks is read with scripts/github-api.sh. A disabled module is refused for any existing repository of the name, managed or not. To adopt an existing repository, keep the module enabled and import it into `github_repository.default[0]`."
+  type        = bool
+  default     = false
+  nullable    = false
+}
+
+variable "allow_destroy" {
+  description = "Allow the repository to be destroyed or replaced when deletion_protection is enabled"
+  type        = bool
+  default     = false
+  nullable    = false
+}
+
 variable "autolink_references" {
   description = "Autolink references"
   type = map(object({
@@ -272,7 +334,7 @@ variable "custom_properties" {
 }
 
 variable "environments" {
-  description = "Environments for the repository. Enviroment secrets should be encrypted using the GitHub public key in Base64 format if prefixed with nacl:. Read more: https://docs.github.com/en/actions/security-for-github-actions/encrypted-secrets"
+  description = "Environments for the repository. Environment secrets are configured with `environment_secrets`, `secrets` is deprecated and refused"
   type = map(object({
     wait_timer          = optional(number, 0)
     can_admins_bypass   = optional(bool, false)
@@ -289,11 +351,11 @@ variable "environments" {
       }), null)
     }), null)
     variables = optional(map(string), null)
-    secrets   = optional(map(string), null)
+    // Deprecated, refused in favor of environment_secrets
+    secrets = optional(map(string), null)
   }))
-  default   = {}
-  sensitive = true
-  nullable  = false
+  default  = {}
+  nullable = false
 
   validation {
     condition     = alltrue([for k, v in var.environments : try(length(v.reviewers.teams) <= 6, true)])
@@ -316,7 +378,20 @@ variable "environments" {
   }
 
   validation {
-    condition     = alltrue([for k, v in var.environments : try(alltrue([for k, v in v.secrets : can(regex("^[a-zA-Z0-9_]+$", k))]), true)])
+    condition     = alltrue([for k, v in var.environments : v.secrets == null])
+    error_message = "Environment secrets are no longer configured with environments[*].secrets. Move them to environment_secrets, keyed by environment name"
+  }
+}
+
+variable "environment_secrets" {
+  description = "Secrets for the repository environments keyed by environment name (if prefixed with nacl: it should be encrypted value using the GitHub public key in Base64 format. Read more: https://docs.github.com/en/actions/security-for-github-actions/encrypted-secrets)"
+  type        = map(map(string))
+  default     = {}
+  sensitive   = true
+  nullable    = false
+
+  validation {
+    condition     = alltrue([for k, v in var.environment_secrets : alltrue([for k, v in v : can(regex("^[a-zA-Z0-9_]+$", k))])])
     error_message = "Environment secrets must be alphanumeric and underscores only, can not start with a number"
   }
 }
@@ -348,14 +423,38 @@ variable "secrets" {
 }
 
 variable "deploy_keys" {
-  description = "Deploy keys for the repository"
+  description = "Deploy keys for the repository. Set `generate` instead of `key` to have the module generate the key pair."
   type = map(object({
     title     = string
-    key       = string
+    key       = optional(string, null)
     read_only = optional(bool, false)
+    generate = optional(object({
+      // ED25519, RSA
+      algorithm = optional(string, "ED25519")
+      rsa_bits  = optional(number, 4096)
+      actions_secret = optional(object({
+        repository  = string
+        secret_name = string
+      }), null)
+    }), null)
   }))
   default  = {}
   nullable = false
+
+  validation {
+    condition     = alltrue([for k, v in var.deploy_keys : (v.key == null) != (v.generate == null)])
+    error_message = "Deploy key must have exactly one of the following: key, generate"
+  }
+
+  validation {
+    condition     = alltrue([for k, v in var.deploy_keys : try(contains(["ED25519", "RSA"], v.generate.algorithm), true)])
+    error_message = "Deploy key generate algorithm must be ED25519 or RSA"
+  }
+
+  validation {
+    condition     = alltrue([for k, v in var.deploy_keys : try(v.generate.actions_secret, null) == null || can(regex("^[A-Za-z_][A-Za-z0-9_]*$", v.generate.actions_secret.secret_name))])
+    error_message = "Deploy key actions secret name must be alphanumeric and underscores only, can not start with a number"
+  }
 }
 
 // https://docs.github.com/en/webhooks/webhook-events-and-payloads
@@ -394,19 +493,31 @@ variable "labels" {
 }
 
 variable "teams" {
-  description = "A map of teams and their permissions for the repository"
+  description = "A map of teams and their permissions for the repository. Permission must be pull, triage, push, maintain, admin or the name of an organization custom repository role"
   type        = map(string)
   default     = {}
   nullable    = false
 }
 
 variable "users" {
-  description = "A map of users and their permissions for the repository"
+  description = "A map of users and their permissions for the repository. Permission must be pull, triage, push, maintain, admin or the name of an organization custom repository role"
   type        = map(string)
   default     = {}
   nullable    = false
 }
 
+variable "collaborators_mode" {
+  description = "Collaborators management mode. Must be authoritative or additive. Authoritative removes teams and users not managed by the module, additive leaves them untouched."
+  type        = string
+  default     = "authoritative"
+  nullable    = false
+
+  validation {
+    condition     = contains(["authoritative", "additive"], var.collaborators_mode)
+    error_message = "Collaborators mode must be authoritative or additive"
+  }
+}
+
 variable "rulesets" {
   description = "A map of rulesets to configure for the repository"
   type = map(object({
@@ -418,8 +529,12 @@ variable "rulesets" {
     bypass_actors = optional(list(object({
       // always, pull_request
       bypass_mode = string
-      actor_id    = optional(string, null)
-      // RepositoryRole, Team, Integration, OrganizationAdmin
+      // RepositoryRole: maintain, write, admin, custom role name or ID
+      // Team: team slug
+      // Integration: GitHub App slug or ID
+      // OrganizationAdmin, DeployKey: not used
+      actor_id = optional(string, null)
+      // RepositoryRole, Team, Integration, OrganizationAdmin, DeployKey
       actor_type = string
     })), [])
     conditions = object({
@@ -447,6 +562,7 @@ variable "rulesets" {
       creation         = optional(bool, false),
       deletion         = optional(bool, false),
       non_fast_forward = optional(bool, false),
+      // Deprecated, use pull_request
       required_pull_request_reviews = optional(object({
         dismiss_stale_reviews           = bool
         required_approving_review_count = number
@@ -488,8 +604,9 @@ variable "rulesets" {
       }), null),
       required_status_checks = optional(object({
         required_check = list(object({
-          context        = string
-          integration_id = optional(number, null)
+          context = string
+          // GitHub App slug or ID
+          integration_id = optional(string, null)
         }))
         strict_required_status_checks_policy = optional(bool, false)
         do_not_enforce_on_create             = optional(bool, false)
@@ -532,13 +649,18 @@ variable "rulesets" {
   }
 
   validation {
-    condition     = alltrue([for k, v in var.rulesets : try(contains(["always", "pull_request"], v.bypass_actors.bypass_mode), true)])
+    condition     = alltrue([for k, v in var.rulesets : alltrue([for b in v.bypass_actors : contains(["always", "pull_request"], b.bypass_mode)])])
     error_message = "Ruleset bypass mode must be always or pull_request"
   }
 
   validation {
-    condition     = alltrue([for k, v in var.rulesets : try(contains(["RepositoryRole", "Team", "Integration", "OrganizationAdmin"], v.bypass_actors.actor_type), true)])
-    error_message = "Ruleset actor type must be RepositoryRole, Team, Integration or OrganizationAdmin"
+    condition     = alltrue([for k, v in var.rulesets : alltrue([for b in v.bypass_actors : contains(["RepositoryRole", "Team", "Integration", "OrganizationAdmin", "DeployKey"], b.actor_type)])])
+    error_message = "Ruleset actor type must be RepositoryRole, Team, Integration, OrganizationAdmin or DeployKey"
+  }
+
+  validation {
+    condition     = alltrue([for k, v in var.rulesets : alltrue([for b in v.bypass_actors : contains(["OrganizationAdmin", "DeployKey"], b.actor_type) || b.actor_id != null])])
+    error_message = "Ruleset actor ID must be specified for RepositoryRole, Team and Integration actor types"
   }
 
   validation {
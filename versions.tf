@@ -1,10 +1,21 @@
 terraform {
-  required_version = ">= 1.0"
+  required_version = ">= 1.5"
 
   required_providers {
     github = {
+      # 6.6.0 and earlier have no fork, source_owner and source_repo arguments for github_repository, and no
+      # code_security, secret_scanning_ai_detection, secret_scanning_non_provider_patterns and
+      # secret_scanning_validity_checks settings of its security_and_analysis
       source  = "integrations/github"
-      version = ">= 6.6.0"
+      version = ">= 6.7.0"
+    }
+    tls = {
+      source  = "hashicorp/tls"
+      version = ">= 4.0.0"
+    }
+    external = {
+      source  = "hashicorp/external"
+      version = ">= 2.3.0"
     }
   }
 }